@@ -13,6 +13,7 @@ func main() {
 		listenAddr = ":" + val
 	}
 	http.HandleFunc("/scrapper", scrapper.Handle)
+	http.HandleFunc("/scheduled", scrapper.HandleTimer)
 	log.Printf("About to listen on %s. Go to https://127.0.0.1%s/", listenAddr, listenAddr)
 	log.Fatal(http.ListenAndServe(listenAddr, nil))
 }
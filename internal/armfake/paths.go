@@ -0,0 +1,54 @@
+package armfake
+
+import "fmt"
+
+// GraphPath is the resource graph query endpoint.
+const GraphPath = "/providers/Microsoft.ResourceGraph/resources"
+
+// SubscriptionsPath is the list of subscriptions visible to the credential.
+const SubscriptionsPath = "/subscriptions"
+
+// ProvidersPath is the list of resource providers of a subscription.
+func ProvidersPath(sub string) string {
+	return fmt.Sprintf("/subscriptions/%s/providers", sub)
+}
+
+// ResourceGroupsPath is the list of resource groups of a subscription.
+func ResourceGroupsPath(sub string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourcegroups", sub)
+}
+
+// ResourcesPath is the list of every resource of a subscription.
+func ResourcesPath(sub string) string {
+	return fmt.Sprintf("/subscriptions/%s/resources", sub)
+}
+
+// VirtualNetworksPath is the list of virtual networks of a subscription.
+func VirtualNetworksPath(sub string) string {
+	return fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Network/virtualNetworks", sub)
+}
+
+// DiskEncryptionSetsPath is the list of disk encryption sets of a subscription.
+func DiskEncryptionSetsPath(sub string) string {
+	return fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Compute/diskEncryptionSets", sub)
+}
+
+// DisksPath is the list of managed disks of a subscription.
+func DisksPath(sub string) string {
+	return fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Compute/disks", sub)
+}
+
+// ClustersPath is the list of managed clusters of a subscription.
+func ClustersPath(sub string) string {
+	return fmt.Sprintf("/subscriptions/%s/providers/Microsoft.ContainerService/managedClusters", sub)
+}
+
+// AgentPoolsPath is the list of agent pools of a managed cluster.
+func AgentPoolsPath(sub string, resourceGroup string, cluster string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s/agentPools", sub, resourceGroup, cluster)
+}
+
+// ClusterID is the resource id of a managed cluster.
+func ClusterID(sub string, resourceGroup string, cluster string) string {
+	return fmt.Sprintf("/subscriptions/%s/resourceGroups/%s/providers/Microsoft.ContainerService/managedClusters/%s", sub, resourceGroup, cluster)
+}
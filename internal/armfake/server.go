@@ -0,0 +1,433 @@
+// Package armfake serves canned Azure Resource Manager list responses and Azure Resource Graph tables over a local
+// TLS server, so sdk clients and whole scrapper runs can be tested offline.
+package armfake
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// Audience is the token audience of the fake resource manager.
+const Audience = "https://management.armfake.local"
+
+// Server is a fake resource manager endpoint.
+// Paths are matched case-insensitively and without query, like ARM does. Lists that were not registered are empty.
+type Server struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	lists    map[string]*list
+	tables   map[string]*list
+	failures map[string][]*failure
+	latency  time.Duration
+	requests []Request
+}
+
+type list struct {
+	pageSize int
+	items    []json.RawMessage
+}
+
+// Failure is an error response injected in place of a list page.
+type Failure struct {
+	// Status is the http status of the response, such as 429, 500 or 403.
+	Status int
+	// Code is the ARM error code of the body, it is derived from Status when empty.
+	Code string
+	// RetryAfter is sent as the Retry-After header when set.
+	RetryAfter time.Duration
+	// Times is how many requests fail before the path recovers, zero or less fails every request.
+	Times int
+	// Page is the page that fails, from 0, so failures can happen in the middle of a nextLink chain.
+	Page int
+}
+
+type failure struct {
+	Failure
+	served int
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method        string
+	Path          string
+	Query         url.Values
+	Authorization string
+	// Graph is the query posted to GraphPath.
+	Graph *GraphQuery
+}
+
+// GraphRow is a row of a resource graph table, a resource along with the subscription and resource group it belongs to.
+type GraphRow struct {
+	SubscriptionID string
+	ResourceGroup  string
+	Resource       any
+}
+
+// GraphQuery is a query posted to the resource graph endpoint.
+type GraphQuery struct {
+	Query         string   `json:"query"`
+	Subscriptions []string `json:"subscriptions"`
+	Options       struct {
+		Top          int    `json:"$top"`
+		SkipToken    string `json:"$skipToken"`
+		ResultFormat string `json:"resultFormat"`
+	} `json:"options"`
+}
+
+// graphQuery matches the queries the server understands, a table optionally filtered by resource type
+// and optionally projected to some of its columns.
+var graphQuery = regexp.MustCompile(`^\s*(\w+)\s*(?:\|\s*where\s+type\s+=~\s+'([^']+)'\s*)?(?:\|\s*project\s+(\w+(?:\s*,\s*\w+)*)\s*)?$`)
+
+// NewServer starts a fake resource manager, it must be closed once the test is done.
+func NewServer() *Server {
+	s := &Server{
+		lists:    map[string]*list{},
+		tables:   map[string]*list{},
+		failures: map[string][]*failure{},
+	}
+	s.srv = httptest.NewTLSServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.srv.Close()
+}
+
+// URL returns the base url of the server.
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Cloud returns a cloud configuration whose resource manager is the server.
+func (s *Server) Cloud() cloud.Configuration {
+	return cloud.Configuration{
+		ActiveDirectoryAuthorityHost: s.srv.URL + "/",
+		Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+			cloud.ResourceManager: {Endpoint: s.srv.URL, Audience: Audience},
+		},
+	}
+}
+
+// ClientOptions returns client options that send every request to the server and retry quickly.
+func (s *Server) ClientOptions() *arm.ClientOptions {
+	return &arm.ClientOptions{
+		ClientOptions: policy.ClientOptions{
+			Cloud:     s.Cloud(),
+			Transport: s.srv.Client(),
+			Retry: policy.RetryOptions{
+				RetryDelay:    time.Millisecond,
+				MaxRetryDelay: 10 * time.Millisecond,
+			},
+		},
+	}
+}
+
+// List serves items as the list at path, split in pages of pageSize items chained by nextLink.
+// A pageSize of zero or less serves every item in a single page.
+func (s *Server) List(path string, pageSize int, items ...any) error {
+	raw := make([]json.RawMessage, 0, len(items))
+	for _, item := range items {
+		b, err := json.Marshal(item)
+		if err != nil {
+			return fmt.Errorf("failed to marshal item of %s: %w", path, err)
+		}
+		raw = append(raw, b)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lists[key(path)] = &list{pageSize: pageSize, items: raw}
+	return nil
+}
+
+// Graph serves rows as the resource graph table, such as resources or resourcecontainers, in pages of at most pageSize
+// rows chained by $skipToken. A pageSize of zero or less only splits pages at the $top of the query.
+func (s *Server) Graph(table string, pageSize int, rows ...GraphRow) error {
+	raw := make([]json.RawMessage, 0, len(rows))
+	for _, row := range rows {
+		b, err := json.Marshal(row.Resource)
+		if err != nil {
+			return fmt.Errorf("failed to marshal row of %s: %w", table, err)
+		}
+		columns := map[string]any{}
+		if err = json.Unmarshal(b, &columns); err != nil {
+			return fmt.Errorf("row of %s is not an object: %w", table, err)
+		}
+		columns["subscriptionId"] = row.SubscriptionID
+		columns["resourceGroup"] = row.ResourceGroup
+		if b, err = json.Marshal(columns); err != nil {
+			return fmt.Errorf("failed to marshal row of %s: %w", table, err)
+		}
+		raw = append(raw, b)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tables[strings.ToLower(table)] = &list{pageSize: pageSize, items: raw}
+	return nil
+}
+
+// Fail injects a failure in the responses of path, failures of the same path are applied in order.
+func (s *Server) Fail(path string, f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures[key(path)] = append(s.failures[key(path)], &failure{Failure: f})
+}
+
+// SetLatency delays every response.
+func (s *Server) SetLatency(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latency = d
+}
+
+// Requests returns the requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request{}, s.requests...)
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("$skiptoken"))
+	method := http.MethodGet
+	var query *GraphQuery
+	if key(r.URL.Path) == key(GraphPath) {
+		method = http.MethodPost
+	}
+	if method == http.MethodPost && r.Method == http.MethodPost {
+		query = &GraphQuery{}
+		if body, err := io.ReadAll(r.Body); err != nil || json.Unmarshal(body, query) != nil {
+			writeError(w, http.StatusBadRequest, "BadRequest", "malformed query")
+			return
+		}
+		page, _ = strconv.Atoi(query.Options.SkipToken)
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method:        r.Method,
+		Path:          r.URL.Path,
+		Query:         r.URL.Query(),
+		Authorization: r.Header.Get("Authorization"),
+		Graph:         query,
+	})
+	latency := s.latency
+	injected := s.failure(key(r.URL.Path), page)
+	l := s.lists[key(r.URL.Path)]
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	switch {
+	case !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer "):
+		writeError(w, http.StatusUnauthorized, "AuthenticationFailed", "missing bearer token")
+	case r.Method != method:
+		writeError(w, http.StatusMethodNotAllowed, "MethodNotAllowed", fmt.Sprintf("%s is not supported", r.Method))
+	case injected != nil:
+		if injected.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(injected.RetryAfter.Round(time.Second)/time.Second)))
+		}
+		code := injected.Code
+		if code == "" {
+			code = errorCode(injected.Status)
+		}
+		writeError(w, injected.Status, code, fmt.Sprintf("injected failure of %s", r.URL.Path))
+	case query != nil:
+		s.writeGraphPage(w, query, page)
+	default:
+		s.writePage(w, r, l, page)
+	}
+}
+
+// failure returns the failure to apply to the page of path and counts it as served, s.mu must be held.
+func (s *Server) failure(path string, page int) *Failure {
+	for _, f := range s.failures[path] {
+		if f.Page != page || (f.Times > 0 && f.served >= f.Times) {
+			continue
+		}
+		f.served++
+		return &f.Failure
+	}
+	return nil
+}
+
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, l *list, page int) {
+	body := struct {
+		Value    []json.RawMessage `json:"value"`
+		NextLink *string           `json:"nextLink,omitempty"`
+	}{Value: []json.RawMessage{}}
+
+	if l != nil {
+		size := l.pageSize
+		if size <= 0 {
+			size = len(l.items)
+		}
+		start := page * size
+		if start < len(l.items) {
+			end := start + size
+			if end > len(l.items) {
+				end = len(l.items)
+			}
+			body.Value = l.items[start:end]
+			if end < len(l.items) {
+				next := nextLink(s.srv.URL, r, page+1)
+				body.NextLink = &next
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+// project keeps the comma separated columns of a row, columns the row does not hold are null as in resource graph.
+func project(row json.RawMessage, columns string) json.RawMessage {
+	if columns == "" {
+		return row
+	}
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(row, &all); err != nil {
+		return row
+	}
+	projected := map[string]json.RawMessage{}
+	for _, column := range strings.Split(columns, ",") {
+		column = strings.TrimSpace(column)
+		projected[column] = json.RawMessage("null")
+		if v, ok := all[column]; ok {
+			projected[column] = v
+		}
+	}
+	b, err := json.Marshal(projected)
+	if err != nil {
+		return row
+	}
+	return b
+}
+
+func (s *Server) writeGraphPage(w http.ResponseWriter, query *GraphQuery, page int) {
+	match := graphQuery.FindStringSubmatch(query.Query)
+	if match == nil {
+		writeError(w, http.StatusBadRequest, "InvalidQuery", fmt.Sprintf("unsupported query %q", query.Query))
+		return
+	}
+	subs := map[string]bool{}
+	for _, sub := range query.Subscriptions {
+		subs[strings.ToLower(sub)] = true
+	}
+
+	s.mu.Lock()
+	t := s.tables[strings.ToLower(match[1])]
+	s.mu.Unlock()
+
+	var rows []json.RawMessage
+	size := query.Options.Top
+	if t != nil {
+		for _, item := range t.items {
+			var columns struct {
+				SubscriptionID string `json:"subscriptionId"`
+				Type           string `json:"type"`
+			}
+			_ = json.Unmarshal(item, &columns)
+			if subs[strings.ToLower(columns.SubscriptionID)] && (match[2] == "" || strings.EqualFold(columns.Type, match[2])) {
+				rows = append(rows, project(item, match[3]))
+			}
+		}
+		if t.pageSize > 0 && (size <= 0 || t.pageSize < size) {
+			size = t.pageSize
+		}
+	}
+	if size <= 0 {
+		size = len(rows)
+	}
+
+	body := struct {
+		TotalRecords    int               `json:"totalRecords"`
+		Count           int               `json:"count"`
+		ResultTruncated string            `json:"resultTruncated"`
+		Data            []json.RawMessage `json:"data"`
+		SkipToken       string            `json:"$skipToken,omitempty"`
+	}{TotalRecords: len(rows), ResultTruncated: "false", Data: []json.RawMessage{}}
+	start := page * size
+	if start < len(rows) {
+		end := start + size
+		if end > len(rows) {
+			end = len(rows)
+		}
+		body.Data = rows[start:end]
+		if end < len(rows) {
+			body.SkipToken = strconv.Itoa(page + 1)
+		}
+	}
+	body.Count = len(body.Data)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func nextLink(base string, r *http.Request, page int) string {
+	q := r.URL.Query()
+	q.Set("$skiptoken", strconv.Itoa(page))
+	return base + r.URL.Path + "?" + q.Encode()
+}
+
+func writeError(w http.ResponseWriter, status int, code string, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("x-ms-error-code", code)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{
+		"error": map[string]string{"code": code, "message": msg},
+	})
+}
+
+func errorCode(status int) string {
+	switch status {
+	case http.StatusTooManyRequests:
+		return "TooManyRequests"
+	case http.StatusForbidden:
+		return "AuthorizationFailed"
+	case http.StatusNotFound:
+		return "ResourceNotFound"
+	case http.StatusInternalServerError:
+		return "InternalServerError"
+	}
+	return strings.ReplaceAll(http.StatusText(status), " ", "")
+}
+
+func key(path string) string {
+	return strings.ToLower(strings.TrimSuffix(path, "/"))
+}
+
+// Credential returns a credential handing out a static token, accepted by the server.
+func Credential() az.TokenCredential {
+	return staticCredential{}
+}
+
+type staticCredential struct{}
+
+func (staticCredential) GetToken(_ context.Context, _ policy.TokenRequestOptions) (az.AccessToken, error) {
+	return az.AccessToken{Token: "armfake", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
@@ -0,0 +1,195 @@
+package armfake_test
+
+import (
+	"azure-scrapper/internal/armfake"
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	graph "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	providers := []any{
+		resource.Provider{Namespace: to("Microsoft.Compute")},
+		resource.Provider{Namespace: to("Microsoft.Network")},
+		resource.Provider{Namespace: to("Microsoft.ContainerService")},
+	}
+
+	tests := []struct {
+		name   string
+		setup  func(srv *armfake.Server)
+		cred   az.TokenCredential
+		expect func(t *testing.T, srv *armfake.Server, namespaces []string, err error)
+	}{
+		{
+			name: "follows nextLink across pages",
+			setup: func(srv *armfake.Server) {
+				require.NoError(t, srv.List(armfake.ProvidersPath("sub"), 2, providers...))
+			},
+			expect: func(t *testing.T, srv *armfake.Server, namespaces []string, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"Microsoft.Compute", "Microsoft.Network", "Microsoft.ContainerService"}, namespaces)
+				requests := srv.Requests()
+				require.Len(t, requests, 2)
+				assert.Equal(t, "1", requests[1].Query.Get("$skiptoken"))
+				assert.NotEmpty(t, requests[1].Query.Get("api-version"))
+				assert.Equal(t, "Bearer armfake", requests[0].Authorization)
+			},
+		},
+		{
+			name: "unregistered lists are empty",
+			expect: func(t *testing.T, srv *armfake.Server, namespaces []string, err error) {
+				require.NoError(t, err)
+				assert.Empty(t, namespaces)
+			},
+		},
+		{
+			name: "transient failure in the middle of the chain is retried",
+			setup: func(srv *armfake.Server) {
+				require.NoError(t, srv.List(armfake.ProvidersPath("sub"), 2, providers...))
+				srv.Fail(armfake.ProvidersPath("sub"), armfake.Failure{Status: http.StatusInternalServerError, Times: 1, Page: 1})
+			},
+			expect: func(t *testing.T, srv *armfake.Server, namespaces []string, err error) {
+				require.NoError(t, err)
+				assert.Len(t, namespaces, 3)
+				assert.Len(t, srv.Requests(), 3)
+			},
+		},
+		{
+			name: "forbidden list returns the arm error",
+			setup: func(srv *armfake.Server) {
+				srv.Fail(armfake.ProvidersPath("sub"), armfake.Failure{Status: http.StatusForbidden})
+			},
+			expect: func(t *testing.T, srv *armfake.Server, namespaces []string, err error) {
+				var respErr *az.ResponseError
+				require.True(t, errors.As(err, &respErr))
+				assert.Equal(t, http.StatusForbidden, respErr.StatusCode)
+				assert.Equal(t, "AuthorizationFailed", respErr.ErrorCode)
+			},
+		},
+		{
+			name: "latency is bounded by the caller context",
+			setup: func(srv *armfake.Server) {
+				srv.SetLatency(time.Second)
+			},
+			expect: func(t *testing.T, srv *armfake.Server, namespaces []string, err error) {
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := armfake.NewServer()
+			defer srv.Close()
+			if tt.setup != nil {
+				tt.setup(srv)
+			}
+
+			client, err := resource.NewProvidersClient("sub", armfake.Credential(), srv.ClientOptions())
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			var namespaces []string
+			pager := client.NewListPager(nil)
+			for pager.More() {
+				var page resource.ProvidersClientListResponse
+				page, err = pager.NextPage(ctx)
+				if err != nil {
+					break
+				}
+				for _, p := range page.Value {
+					namespaces = append(namespaces, *p.Namespace)
+				}
+			}
+			tt.expect(t, srv, namespaces, err)
+		})
+	}
+}
+
+func TestServer_Graph(t *testing.T) {
+	srv := armfake.NewServer()
+	defer srv.Close()
+	disk := func(sub string, name string) armfake.GraphRow {
+		return armfake.GraphRow{SubscriptionID: sub, ResourceGroup: "rg", Resource: map[string]string{
+			"id":   "/subscriptions/" + sub + "/resourceGroups/rg/providers/Microsoft.Compute/disks/" + name,
+			"type": "microsoft.compute/disks",
+		}}
+	}
+	require.NoError(t, srv.Graph("resources", 2,
+		disk("sub-a", "a-1"), disk("sub-b", "b-1"), disk("sub-c", "c-1"), disk("sub-a", "a-2"),
+		armfake.GraphRow{SubscriptionID: "sub-a", Resource: map[string]string{"type": "microsoft.network/virtualnetworks"}},
+	))
+	srv.Fail(armfake.GraphPath, armfake.Failure{Status: http.StatusTooManyRequests, Times: 1, Page: 1})
+
+	client, err := graph.NewClient(armfake.Credential(), srv.ClientOptions())
+	require.NoError(t, err)
+
+	var ids []string
+	request := graph.QueryRequest{
+		Query:         to("resources | where type =~ 'Microsoft.Compute/disks'"),
+		Subscriptions: []*string{to("sub-a"), to("SUB-B")},
+		Options:       &graph.QueryRequestOptions{ResultFormat: to(graph.ResultFormatObjectArray)},
+	}
+	for {
+		resp, err := client.Resources(context.Background(), request, nil)
+		require.NoError(t, err)
+		for _, row := range resp.Data.([]any) {
+			ids = append(ids, row.(map[string]any)["id"].(string))
+		}
+		if resp.SkipToken == nil {
+			break
+		}
+		request.Options.SkipToken = resp.SkipToken
+	}
+	assert.Equal(t, []string{
+		"/subscriptions/sub-a/resourceGroups/rg/providers/Microsoft.Compute/disks/a-1",
+		"/subscriptions/sub-b/resourceGroups/rg/providers/Microsoft.Compute/disks/b-1",
+		"/subscriptions/sub-a/resourceGroups/rg/providers/Microsoft.Compute/disks/a-2",
+	}, ids)
+	requests := srv.Requests()
+	require.Len(t, requests, 3, "the throttled page is retried")
+	assert.Equal(t, http.MethodPost, requests[0].Method)
+	assert.Equal(t, "1", requests[2].Graph.Options.SkipToken)
+
+	projected, err := client.Resources(context.Background(), graph.QueryRequest{
+		Query:         to("resources | where type =~ 'microsoft.network/virtualnetworks' | project type, name"),
+		Subscriptions: []*string{to("sub-a")},
+		Options:       &graph.QueryRequestOptions{ResultFormat: to(graph.ResultFormatObjectArray)},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []any{map[string]any{"type": "microsoft.network/virtualnetworks", "name": nil}}, projected.Data)
+
+	_, err = client.Resources(context.Background(), graph.QueryRequest{Query: to("resources | summarize count()"), Subscriptions: []*string{to("sub-a")}}, nil)
+	var respErr *az.ResponseError
+	require.ErrorAs(t, err, &respErr)
+	assert.Equal(t, "InvalidQuery", respErr.ErrorCode)
+}
+
+func TestServer_RequiresBearerToken(t *testing.T) {
+	srv := armfake.NewServer()
+	defer srv.Close()
+
+	resp, err := srv.ClientOptions().Transport.Do(mustRequest(t, srv.URL()+armfake.ProvidersPath("sub")))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func mustRequest(t *testing.T, url string) *http.Request {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	return req
+}
+
+func to[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,245 @@
+// Package recorder records the ARM http exchanges of a scrape into a cassette file and replays them without network,
+// so customer issues can be reproduced and whole runs tested against real shaped data.
+package recorder
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// Mode selects whether a Recorder records or replays exchanges.
+type Mode string
+
+const (
+	ModeRecord Mode = "record"
+	ModeReplay Mode = "replay"
+)
+
+// Cassette holds the recorded exchanges of a scrape.
+type Cassette struct {
+	RecordedAt   time.Time     `json:"recordedAt"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded request and its response.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request, without its credentials.
+// BodyHash is the sha256 of the request body, it tells apart the requests posted to the same url such as resource graph queries.
+type Request struct {
+	Method   string      `json:"method"`
+	URL      string      `json:"url"`
+	BodyHash string      `json:"bodyHash,omitempty"`
+	Headers  http.Header `json:"headers,omitempty"`
+}
+
+// Response is a recorded response, its body is scrubbed of secrets.
+type Response struct {
+	StatusCode int         `json:"statusCode"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Recorder is a transport that records exchanges sent through it, or replays the exchanges of a cassette.
+type Recorder struct {
+	mode      Mode
+	path      string
+	transport policy.Transporter
+
+	mu       sync.Mutex
+	cassette Cassette
+	// pending holds the interactions not replayed yet, by request.
+	pending map[string][]Interaction
+}
+
+// New creates a recorder for the cassette at path. In record mode requests are sent through transport,
+// http.DefaultClient when nil, and the cassette is written by Save. In replay mode the cassette is read from path.
+func New(path string, mode Mode, transport policy.Transporter) (*Recorder, error) {
+	r := &Recorder{mode: mode, path: path, transport: transport}
+	switch mode {
+	case ModeRecord:
+		if r.transport == nil {
+			r.transport = http.DefaultClient
+		}
+		r.cassette.RecordedAt = time.Now().UTC()
+	case ModeReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err = json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("malformed cassette %s: %w", path, err)
+		}
+		r.pending = map[string][]Interaction{}
+		for _, i := range r.cassette.Interactions {
+			k := requestKey(i.Request.Method, i.Request.URL, i.Request.BodyHash)
+			r.pending[k] = append(r.pending[k], i)
+		}
+	default:
+		return nil, fmt.Errorf("unknown recorder mode %q", mode)
+	}
+	return r, nil
+}
+
+// Mode returns whether the recorder records or replays.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Do records or replays a request.
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeReplay {
+		return r.replay(req)
+	}
+	return r.record(req)
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	hash, err := bodyHash(req)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := r.transport.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response of %s %s: %w", req.Method, req.URL, err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: Request{
+			Method:   req.Method,
+			URL:      req.URL.String(),
+			BodyHash: hash,
+			Headers:  scrubHeaders(req.Header),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Headers:    scrubHeaders(resp.Header),
+			Body:       string(scrubBody(body)),
+		},
+	})
+	return resp, nil
+}
+
+// replay serves the recorded responses of a request in the order they were recorded,
+// the last one is served again once they were all replayed.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	hash, err := bodyHash(req)
+	if err != nil {
+		return nil, err
+	}
+	k := requestKey(req.Method, req.URL.String(), hash)
+
+	r.mu.Lock()
+	recorded := r.pending[k]
+	if len(recorded) == 0 {
+		r.mu.Unlock()
+		return nil, fmt.Errorf("no recorded interaction for %s %s", req.Method, req.URL)
+	}
+	i := recorded[0]
+	if len(recorded) > 1 {
+		r.pending[k] = recorded[1:]
+	}
+	r.mu.Unlock()
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", i.Response.StatusCode, http.StatusText(i.Response.StatusCode)),
+		StatusCode:    i.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        i.Response.Headers.Clone(),
+		Body:          io.NopCloser(strings.NewReader(i.Response.Body)),
+		ContentLength: int64(len(i.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Cassette returns a copy of the recorded interactions.
+func (r *Recorder) Cassette() Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c := r.cassette
+	c.Interactions = append([]Interaction{}, r.cassette.Interactions...)
+	return c
+}
+
+// Save writes the recorded cassette to its path, it does nothing in replay mode.
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.Cassette(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+	if err = os.WriteFile(r.path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// requestKey identifies a request, its query is normalized as nextLinks do not keep the parameters order.
+func requestKey(method string, rawURL string, bodyHash string) string {
+	if u, err := url.Parse(rawURL); err == nil {
+		u.RawQuery = u.Query().Encode()
+		rawURL = u.String()
+	}
+	key := method + " " + strings.ToLower(rawURL)
+	if bodyHash != "" {
+		key += " " + bodyHash
+	}
+	return key
+}
+
+// bodyHash returns the sha256 of the request body, empty for requests without one. The body is left readable.
+func bodyHash(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read request body of %s %s: %w", req.Method, req.URL, err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	if len(body) == 0 {
+		return "", nil
+	}
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Credential returns a credential handing out a static token, replayed requests are never authenticated.
+func Credential() az.TokenCredential {
+	return staticCredential{}
+}
+
+type staticCredential struct{}
+
+func (staticCredential) GetToken(_ context.Context, _ policy.TokenRequestOptions) (az.AccessToken, error) {
+	return az.AccessToken{Token: Redacted, ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
@@ -0,0 +1,222 @@
+package recorder_test
+
+import (
+	"azure-scrapper/internal/armfake"
+	"azure-scrapper/internal/recorder"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	graph "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	srv := armfake.NewServer()
+	require.NoError(t, srv.List(armfake.ClustersPath("sub"), 1,
+		container.ManagedCluster{
+			Name: to("aks-1"),
+			Properties: &container.ManagedClusterProperties{
+				ServicePrincipalProfile: &container.ManagedClusterServicePrincipalProfile{ClientID: to("client"), Secret: to("s3cr3t")},
+				WindowsProfile:          &container.ManagedClusterWindowsProfile{AdminUsername: to("admin"), AdminPassword: to("p4ssw0rd")},
+			},
+		},
+		container.ManagedCluster{Name: to("aks-2")},
+	))
+	srv.Fail(armfake.ClustersPath("sub"), armfake.Failure{Status: http.StatusInternalServerError, Times: 1, Page: 1})
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, err := recorder.New(path, recorder.ModeRecord, srv.ClientOptions().Transport)
+	require.NoError(t, err)
+	recorded := listClusters(t, srv.ClientOptions(), rec, armfake.Credential())
+	require.NoError(t, rec.Save())
+	srv.Close()
+
+	assert.Equal(t, []string{"aks-1", "aks-2"}, names(recorded))
+	assert.Equal(t, "s3cr3t", *recorded[0].Properties.ServicePrincipalProfile.Secret)
+	assert.Len(t, rec.Cassette().Interactions, 3)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "Bearer armfake")
+	assert.NotContains(t, string(data), "s3cr3t")
+	assert.NotContains(t, string(data), "p4ssw0rd")
+	assert.Contains(t, string(data), "admin")
+
+	replay, err := recorder.New(path, recorder.ModeReplay, nil)
+	require.NoError(t, err)
+	replayed := listClusters(t, srv.ClientOptions(), replay, recorder.Credential())
+	assert.Equal(t, []string{"aks-1", "aks-2"}, names(replayed))
+	assert.Equal(t, recorder.Redacted, *replayed[0].Properties.ServicePrincipalProfile.Secret)
+	assert.Equal(t, "client", *replayed[0].Properties.ServicePrincipalProfile.ClientID)
+}
+
+func TestRecorder_ResourceGraph(t *testing.T) {
+	srv := armfake.NewServer()
+	row := func(name string, resourceType string) armfake.GraphRow {
+		return armfake.GraphRow{SubscriptionID: "sub", ResourceGroup: "rg", Resource: map[string]string{"name": name, "type": resourceType}}
+	}
+	require.NoError(t, srv.Graph("resources", 1,
+		row("disk-1", "microsoft.compute/disks"), row("disk-2", "microsoft.compute/disks"), row("vnet", "microsoft.network/virtualnetworks"),
+	))
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, err := recorder.New(path, recorder.ModeRecord, srv.ClientOptions().Transport)
+	require.NoError(t, err)
+	disks, diskTokens := queryGraph(t, srv.ClientOptions(), rec, armfake.Credential(), "microsoft.compute/disks")
+	vnets, _ := queryGraph(t, srv.ClientOptions(), rec, armfake.Credential(), "microsoft.network/virtualnetworks")
+	require.NoError(t, rec.Save())
+	srv.Close()
+	assert.Equal(t, []string{"disk-1", "disk-2"}, disks)
+	assert.Equal(t, []string{"1"}, diskTokens)
+	assert.Equal(t, []string{"vnet"}, vnets)
+
+	replay, err := recorder.New(path, recorder.ModeReplay, nil)
+	require.NoError(t, err)
+	replayedVnets, _ := queryGraph(t, srv.ClientOptions(), replay, recorder.Credential(), "microsoft.network/virtualnetworks")
+	replayedDisks, replayedTokens := queryGraph(t, srv.ClientOptions(), replay, recorder.Credential(), "microsoft.compute/disks")
+	assert.Equal(t, vnets, replayedVnets, "queries are replayed by body, not in the recorded order")
+	assert.Equal(t, disks, replayedDisks)
+	assert.Equal(t, diskTokens, replayedTokens, "skip tokens are not scrubbed")
+}
+
+func TestRecorder_Scrub(t *testing.T) {
+	body := `{"properties":{"primaryConnectionString":"Endpoint=sb://ns;SharedAccessKey=k3y","storageAccountKey":"st0r4ge",` +
+		`"clientSecret":"s3cr3t","tokenLifetime":"PT1H","name":"ns"},"$skipToken":"page-2","nextLink":"https://next?$skiptoken=page-2"}`
+	transport := transportFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{"Set-Cookie": {"session=c00kie"}, "Content-Type": {"application/json"}}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+	})
+
+	rec, err := recorder.New(filepath.Join(t.TempDir(), "cassette.json"), recorder.ModeRecord, transport)
+	require.NoError(t, err)
+	req, err := http.NewRequest(http.MethodGet, "https://management.azure.com/namespaces", nil)
+	require.NoError(t, err)
+	req.Header.Set("Cookie", "session=c00kie")
+	resp, err := rec.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	interactions := rec.Cassette().Interactions
+	require.Len(t, interactions, 1)
+	assert.Equal(t, recorder.Redacted, interactions[0].Request.Headers.Get("Cookie"))
+	assert.Equal(t, recorder.Redacted, interactions[0].Response.Headers.Get("Set-Cookie"))
+
+	var recorded struct {
+		Properties map[string]string `json:"properties"`
+		SkipToken  string            `json:"$skipToken"`
+		NextLink   string            `json:"nextLink"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(interactions[0].Response.Body), &recorded))
+	assert.Equal(t, map[string]string{
+		"primaryConnectionString": recorder.Redacted,
+		"storageAccountKey":       recorder.Redacted,
+		"clientSecret":            recorder.Redacted,
+		"tokenLifetime":           recorder.Redacted,
+		"name":                    "ns",
+	}, recorded.Properties)
+	assert.Equal(t, "page-2", recorded.SkipToken, "paging properties are recorded as is")
+	assert.Equal(t, "https://next?$skiptoken=page-2", recorded.NextLink)
+}
+
+type transportFunc func(req *http.Request) (*http.Response, error)
+
+func (f transportFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRecorder_Replay(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "unrecorded request",
+			content: `{"interactions":[]}`,
+			wantErr: "no recorded interaction for GET",
+		},
+		{
+			name:    "malformed cassette",
+			content: `{"interactions":`,
+			wantErr: "malformed cassette",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cassette.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			rec, err := recorder.New(path, recorder.ModeReplay, nil)
+			if err == nil {
+				var req *http.Request
+				req, err = http.NewRequest(http.MethodGet, "https://management.azure.com/subscriptions", nil)
+				require.NoError(t, err)
+				_, err = rec.Do(req)
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func listClusters(t *testing.T, opts *arm.ClientOptions, rec *recorder.Recorder, cred az.TokenCredential) []*container.ManagedCluster {
+	opts.Transport = rec
+	client, err := container.NewManagedClustersClient("sub", cred, opts)
+	require.NoError(t, err)
+
+	var clusters []*container.ManagedCluster
+	pager := client.NewListPager(nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		require.NoError(t, err)
+		clusters = append(clusters, page.Value...)
+	}
+	return clusters
+}
+
+// queryGraph lists the names of the resources of a type from the resource graph, following every skip token it returns.
+func queryGraph(t *testing.T, opts *arm.ClientOptions, rec *recorder.Recorder, cred az.TokenCredential, resourceType string) ([]string, []string) {
+	opts.Transport = rec
+	client, err := graph.NewClient(cred, opts)
+	require.NoError(t, err)
+
+	var names, tokens []string
+	request := graph.QueryRequest{
+		Query:         to("resources | where type =~ '" + resourceType + "'"),
+		Subscriptions: []*string{to("sub")},
+		Options:       &graph.QueryRequestOptions{ResultFormat: to(graph.ResultFormatObjectArray)},
+	}
+	for {
+		resp, err := client.Resources(context.Background(), request, nil)
+		require.NoError(t, err)
+		for _, row := range resp.Data.([]any) {
+			names = append(names, row.(map[string]any)["name"].(string))
+		}
+		if resp.SkipToken == nil {
+			return names, tokens
+		}
+		tokens = append(tokens, *resp.SkipToken)
+		request.Options.SkipToken = resp.SkipToken
+	}
+}
+
+func names(clusters []*container.ManagedCluster) []string {
+	var n []string
+	for _, c := range clusters {
+		n = append(n, *c.Name)
+	}
+	return n
+}
+
+func to[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,78 @@
+package recorder
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// Redacted replaces scrubbed values.
+const Redacted = "REDACTED"
+
+// sensitiveHeaders are redacted from recorded headers.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Ms-Authorization-Auxiliary"}
+
+// sensitiveKeys are the json property names, or parts of them, whose values are scrubbed from recorded bodies.
+var sensitiveKeys = []string{"secret", "password", "token", "connectionstring", "accesskey", "accountkey", "primarykey", "secondarykey"}
+
+// pagingKeys are the lower cased paging properties recorded as is, replaying a listing needs them.
+var pagingKeys = map[string]bool{"$skiptoken": true, "skiptoken": true, "nextlink": true}
+
+func scrubHeaders(h http.Header) http.Header {
+	h = h.Clone()
+	for _, name := range sensitiveHeaders {
+		if h.Get(name) != "" {
+			h.Set(name, Redacted)
+		}
+	}
+	return h
+}
+
+// scrubBody replaces the values of sensitive properties of a json body, other bodies are recorded as is.
+func scrubBody(body []byte) []byte {
+	var v any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return body
+	}
+	scrubbed, err := json.Marshal(scrubValue(v))
+	if err != nil {
+		return body
+	}
+	return scrubbed
+}
+
+func scrubValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if isSensitive(k) {
+				if _, ok := val.(string); ok {
+					v[k] = Redacted
+					continue
+				}
+			}
+			v[k] = scrubValue(val)
+		}
+	case []any:
+		for i, val := range v {
+			v[i] = scrubValue(val)
+		}
+	}
+	return v
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	if pagingKeys[key] {
+		return false
+	}
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package scrapper_test
+
+import (
+	"azure-scrapper/internal/armfake"
+	"azure-scrapper/internal/recorder"
+	. "azure-scrapper/internal/scrapper"
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapper_RunAgainstFakeARM(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, srv *armfake.Server)
+		options []OptionsFunc
+		expect  func(t *testing.T, report *RunReport, records []Record, err error)
+	}{
+		{
+			name: "whole run follows every nextLink",
+			expect: func(t *testing.T, report *RunReport, records []Record, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 3, report.Kinds[KindCluster].Records)
+				assert.Equal(t, 2, report.Kinds[KindCluster].Pages)
+				assert.Equal(t, 5, report.Kinds[KindNodePool].Records)
+				assert.Equal(t, 2, report.Kinds[KindResourceGroup].Records)
+				assert.Equal(t, 0, report.Kinds[KindVirtualNetwork].Records)
+			},
+		},
+		{
+			name: "transient server errors are retried",
+			setup: func(t *testing.T, srv *armfake.Server) {
+				srv.Fail(armfake.ClustersPath("sub"), armfake.Failure{Status: http.StatusInternalServerError, Times: 2, Page: 1})
+				srv.Fail(armfake.ResourceGroupsPath("sub"), armfake.Failure{Status: http.StatusTooManyRequests, Times: 1})
+			},
+			expect: func(t *testing.T, report *RunReport, records []Record, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, 3, report.Kinds[KindCluster].Records)
+				assert.Equal(t, 2, report.Kinds[KindResourceGroup].Records)
+			},
+		},
+		{
+			name: "forbidden kind is reported with partial results",
+			setup: func(t *testing.T, srv *armfake.Server) {
+				srv.Fail(armfake.ResourceGroupsPath("sub"), armfake.Failure{Status: http.StatusForbidden})
+			},
+			options: []OptionsFunc{WithPartialResults()},
+			expect: func(t *testing.T, report *RunReport, records []Record, err error) {
+				require.NoError(t, err)
+				assert.True(t, report.Failed())
+				assert.ErrorContains(t, report.Kinds[KindResourceGroup].Err, "AuthorizationFailed")
+				assert.Equal(t, 5, report.Kinds[KindNodePool].Records)
+			},
+		},
+		{
+			name: "failing node pool page fails the run",
+			setup: func(t *testing.T, srv *armfake.Server) {
+				srv.Fail(armfake.AgentPoolsPath("sub", "rg", "aks-1"), armfake.Failure{Status: http.StatusForbidden, Page: 1})
+			},
+			expect: func(t *testing.T, report *RunReport, records []Record, err error) {
+				var kindErr *KindError
+				require.ErrorAs(t, err, &kindErr)
+				assert.Equal(t, KindNodePool, kindErr.Kind)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := armfake.NewServer()
+			defer srv.Close()
+
+			require.NoError(t, srv.List(armfake.ClustersPath("sub"), 2,
+				cluster("sub", "rg", "aks-1"), cluster("sub", "rg", "aks-2"), cluster("sub", "other", "aks-3")))
+			require.NoError(t, srv.List(armfake.AgentPoolsPath("sub", "rg", "aks-1"), 1,
+				container.AgentPool{Name: to("system")}, container.AgentPool{Name: to("user")}, container.AgentPool{Name: to("gpu")}))
+			require.NoError(t, srv.List(armfake.AgentPoolsPath("sub", "rg", "aks-2"), 0, container.AgentPool{Name: to("system")}))
+			require.NoError(t, srv.List(armfake.AgentPoolsPath("sub", "other", "aks-3"), 0, container.AgentPool{Name: to("system")}))
+			require.NoError(t, srv.List(armfake.ResourceGroupsPath("sub"), 0,
+				resource.ResourceGroup{Name: to("rg")}, resource.ResourceGroup{Name: to("other")}))
+			if tt.setup != nil {
+				tt.setup(t, srv)
+			}
+
+			sink := NewMemorySink()
+			s, err := NewScrapper(armfake.Credential(), "sub",
+				append([]OptionsFunc{WithSink(sink), WithClientOptions(srv.ClientOptions())}, tt.options...)...)
+			require.NoError(t, err)
+
+			report, err := s.Run(context.Background())
+			tt.expect(t, report, sink.Records(), err)
+		})
+	}
+}
+
+func TestScrapper_RunReplay(t *testing.T) {
+	srv := armfake.NewServer()
+	require.NoError(t, srv.List(armfake.ClustersPath("sub"), 1, cluster("sub", "rg", "aks-1"), cluster("sub", "rg", "aks-2")))
+	require.NoError(t, srv.List(armfake.AgentPoolsPath("sub", "rg", "aks-1"), 0, container.AgentPool{Name: to("system")}))
+	require.NoError(t, srv.List(armfake.ResourceGroupsPath("sub"), 0, resource.ResourceGroup{Name: to("rg")}))
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	rec, err := recorder.New(path, recorder.ModeRecord, srv.ClientOptions().Transport)
+	require.NoError(t, err)
+	recorded := NewMemorySink()
+	s, err := NewScrapper(armfake.Credential(), "sub", WithSink(recorded), WithClientOptions(srv.ClientOptions()), WithTransport(rec))
+	require.NoError(t, err)
+	_, err = s.Run(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, rec.Save())
+	srv.Close()
+
+	replay, err := recorder.New(path, recorder.ModeReplay, nil)
+	require.NoError(t, err)
+	replayed := NewMemorySink()
+	s, err = NewScrapper(recorder.Credential(), "sub", WithSink(replayed), WithClientOptions(srv.ClientOptions()), WithTransport(replay))
+	require.NoError(t, err)
+	report, err := s.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, report.Kinds[KindCluster].Records)
+	assert.Equal(t, 1, report.Kinds[KindNodePool].Records)
+	assert.Equal(t, 1, report.Kinds[KindResourceGroup].Records)
+	assert.Len(t, replayed.Records(), len(recorded.Records()))
+}
+
+func cluster(sub string, rg string, name string) container.ManagedCluster {
+	return container.ManagedCluster{ID: to(armfake.ClusterID(sub, rg, name)), Name: to(name)}
+}
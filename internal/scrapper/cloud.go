@@ -0,0 +1,90 @@
+package scrapper
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+)
+
+// CloudFromName returns the configuration of a well known azure cloud: public, usgov or china.
+func CloudFromName(name string) (cloud.Configuration, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "public", "azurepublic", "azurecloud":
+		return cloud.AzurePublic, nil
+	case "usgov", "azuregovernment", "azureusgovernment":
+		return cloud.AzureGovernment, nil
+	case "china", "azurechina", "azurechinacloud":
+		return cloud.AzureChina, nil
+	}
+	return cloud.Configuration{}, fmt.Errorf("unknown cloud %q", name)
+}
+
+// cloudConfigFile is the json layout of a custom cloud configuration file.
+type cloudConfigFile struct {
+	ActiveDirectoryAuthorityHost string `json:"activeDirectoryAuthorityHost"`
+	ResourceManager              struct {
+		Endpoint string `json:"endpoint"`
+		Audience string `json:"audience"`
+	} `json:"resourceManager"`
+}
+
+// LoadCloudConfig reads a custom cloud configuration from a json file such as
+//
+//	{"activeDirectoryAuthorityHost": "https://login.example/", "resourceManager": {"endpoint": "https://management.example", "audience": "https://management.example"}}
+func LoadCloudConfig(path string) (cloud.Configuration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cloud.Configuration{}, fmt.Errorf("failed to read cloud config: %w", err)
+	}
+
+	var file cloudConfigFile
+	if err = json.Unmarshal(data, &file); err != nil {
+		return cloud.Configuration{}, fmt.Errorf("malformed cloud config %s: %w", path, err)
+	}
+	if file.ActiveDirectoryAuthorityHost == "" {
+		return cloud.Configuration{}, fmt.Errorf("cloud config %s: missing activeDirectoryAuthorityHost", path)
+	}
+	if file.ResourceManager.Endpoint == "" || file.ResourceManager.Audience == "" {
+		return cloud.Configuration{}, fmt.Errorf("cloud config %s: missing resourceManager endpoint or audience", path)
+	}
+
+	return cloud.Configuration{
+		ActiveDirectoryAuthorityHost: file.ActiveDirectoryAuthorityHost,
+		Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+			cloud.ResourceManager: {
+				Endpoint: file.ResourceManager.Endpoint,
+				Audience: file.ResourceManager.Audience,
+			},
+		},
+	}, nil
+}
+
+// cloudFromEnv reads the cloud to scrape, AZURE_CLOUD_CONFIG is the path of a custom cloud configuration file
+// and takes precedence over AZURE_CLOUD, the name of a well known cloud. It returns nil when neither is set.
+func cloudFromEnv() (*cloud.Configuration, error) {
+	if path := os.Getenv("AZURE_CLOUD_CONFIG"); path != "" {
+		cfg, err := LoadCloudConfig(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AZURE_CLOUD_CONFIG: %w", err)
+		}
+		return &cfg, nil
+	}
+	if name := os.Getenv("AZURE_CLOUD"); name != "" {
+		cfg, err := CloudFromName(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AZURE_CLOUD: %w", err)
+		}
+		return &cfg, nil
+	}
+	return nil, nil
+}
+
+// WithCloud sets the cloud every client is created for, replacing the cloud of the configured client options.
+func WithCloud(cfg cloud.Configuration) OptionsFunc {
+	return func(opt *Options) {
+		opt.cloud = &cfg
+	}
+}
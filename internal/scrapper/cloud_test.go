@@ -0,0 +1,146 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloudFromName(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    cloud.Configuration
+		wantErr string
+	}{
+		{name: "", want: cloud.AzurePublic},
+		{name: "public", want: cloud.AzurePublic},
+		{name: "USGov", want: cloud.AzureGovernment},
+		{name: "china", want: cloud.AzureChina},
+		{name: "mars", wantErr: `unknown cloud "mars"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg, err := CloudFromName(tt.name)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.ActiveDirectoryAuthorityHost, cfg.ActiveDirectoryAuthorityHost)
+			assert.Equal(t, tt.want.Services[cloud.ResourceManager], cfg.Services[cloud.ResourceManager])
+		})
+	}
+}
+
+func TestLoadCloudConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    func(t *testing.T, cfg cloud.Configuration, err error)
+	}{
+		{
+			name:    "custom endpoints",
+			content: `{"activeDirectoryAuthorityHost":"https://login.example/","resourceManager":{"endpoint":"https://management.example","audience":"https://management.example/"}}`,
+			want: func(t *testing.T, cfg cloud.Configuration, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "https://login.example/", cfg.ActiveDirectoryAuthorityHost)
+				assert.Equal(t, "https://management.example", cfg.Services[cloud.ResourceManager].Endpoint)
+				assert.Equal(t, "https://management.example/", cfg.Services[cloud.ResourceManager].Audience)
+			},
+		},
+		{
+			name:    "malformed file",
+			content: `{"activeDirectoryAuthorityHost":`,
+			want: func(t *testing.T, cfg cloud.Configuration, err error) {
+				assert.ErrorContains(t, err, "malformed cloud config")
+			},
+		},
+		{
+			name:    "missing resource manager",
+			content: `{"activeDirectoryAuthorityHost":"https://login.example/"}`,
+			want: func(t *testing.T, cfg cloud.Configuration, err error) {
+				assert.ErrorContains(t, err, "missing resourceManager endpoint or audience")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cloud.json")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0o600))
+
+			cfg, err := LoadCloudConfig(path)
+			tt.want(t, cfg, err)
+		})
+	}
+}
+
+func TestWithCloud(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.Path)
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"value":[{"namespace":"Microsoft.Compute"}]}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "cloud.json")
+	content := `{"activeDirectoryAuthorityHost":"https://login.example/","resourceManager":{"endpoint":"` + srv.URL + `","audience":"https://management.example"}}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	cfg, err := LoadCloudConfig(path)
+	require.NoError(t, err)
+
+	cred := &scopeRecordingCred{}
+	sink := NewMemorySink()
+	s, err := NewScrapper(cred, "sub", WithCloud(cfg), WithSink(sink),
+		WithClientOptions(&arm.ClientOptions{ClientOptions: policy.ClientOptions{Transport: srv.Client()}}),
+	)
+	require.NoError(t, err)
+
+	var providers []*resource.Provider
+	err = s.ListProviders(context.Background(), func(p *resource.Provider) error {
+		providers = append(providers, p)
+		return nil
+	})
+	require.NoError(t, err)
+
+	require.Len(t, providers, 1)
+	assert.Equal(t, "Microsoft.Compute", *providers[0].Namespace)
+	assert.Equal(t, []string{"/subscriptions/sub/providers"}, paths)
+	assert.Contains(t, cred.scopes(), "https://management.example/.default")
+}
+
+// scopeRecordingCred hands out a static token and records the scopes it was requested for.
+type scopeRecordingCred struct {
+	mu        sync.Mutex
+	requested []string
+}
+
+func (c *scopeRecordingCred) GetToken(_ context.Context, opts policy.TokenRequestOptions) (az.AccessToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requested = append(c.requested, opts.Scopes...)
+	return az.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func (c *scopeRecordingCred) scopes() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.requested...)
+}
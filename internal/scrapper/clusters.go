@@ -2,34 +2,56 @@ package scrapper
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
 	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
 )
 
+const KindCluster Kind = "managedCluster"
+
 // ClusterPager used to scrape aks cluster information
 type ClusterPager interface {
 	NewListPager(options *container.ManagedClustersClientListOptions) *rt.Pager[container.ManagedClustersClientListResponse]
+	NewListByResourceGroupPager(resourceGroupName string, options *container.ManagedClustersClientListByResourceGroupOptions) *rt.Pager[container.ManagedClustersClientListByResourceGroupResponse]
 }
 
-type ClusterClientFactory func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (ClusterPager, error)
+type ClusterClientFactory = ClientFactory[ClusterPager]
+
+func init() {
+	Register(Collector[ClusterPager]{
+		Kind: KindCluster,
+		Factory: func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (ClusterPager, error) {
+			return container.NewManagedClustersClient(subscriptionID, credential, options)
+		},
+		Collect: func(ctx context.Context, c *Collection, _ ClusterPager) error {
+			return c.Scrapper().ListClusters(ctx, emitHandler[container.ManagedCluster](c, KindCluster))
+		},
+		ListByResourceGroup: func(ctx context.Context, c *Collection, _ ClusterPager, resourceGroup string) error {
+			return c.Scrapper().ListClustersByResourceGroup(ctx, resourceGroup, emitHandler[container.ManagedCluster](c, KindCluster))
+		},
+		Graph: &GraphQuery{
+			Query: graphResources("microsoft.containerservice/managedclusters"),
+			Collect: func(ctx context.Context, c *Collection, rows []json.RawMessage) error {
+				return graphRows(ctx, rows, emitHandler[container.ManagedCluster](c, KindCluster))
+			},
+		},
+	})
+}
 
-func defaultClusterClientFactory(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (ClusterPager, error) {
-	return container.NewManagedClustersClient(subscriptionID, credential, options)
+func WithClusterFactory(f ClusterClientFactory) OptionsFunc {
+	return WithFactory(KindCluster, f)
 }
 
 func (s *Scrapper) ListClusters(ctx context.Context, pageHandler pageHandler[container.ManagedCluster]) error {
-	pager := s.clusterClient.NewListPager(nil)
-	for pager.More() {
-		page, err := pager.NextPage(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to advance page: %w", err)
-		}
-		if err = processPage(page.Value, err, pageHandler); err != nil {
-			return err
-		}
-	}
-	return nil
+	pager := clientFor[ClusterPager](s, KindCluster).NewListPager(nil)
+	return listPages(ctx, pager, func(p container.ManagedClustersClientListResponse) []*container.ManagedCluster { return p.Value }, pageHandler)
+}
+
+func (s *Scrapper) ListClustersByResourceGroup(ctx context.Context, resourceGroup string, pageHandler pageHandler[container.ManagedCluster]) error {
+	pager := clientFor[ClusterPager](s, KindCluster).NewListByResourceGroupPager(resourceGroup, nil)
+	return listPages(ctx, pager, func(p container.ManagedClustersClientListByResourceGroupResponse) []*container.ManagedCluster {
+		return p.Value
+	}, pageHandler)
 }
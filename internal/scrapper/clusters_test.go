@@ -2,11 +2,10 @@ package scrapper_test
 
 import (
 	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
 	"context"
 	"errors"
 
-	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,37 +20,23 @@ func TestScrapper_ListClusters(t *testing.T) {
 		expect               func(t *testing.T, err error)
 	}{
 		{
-			name: "cluster iteration succeeds",
-			clusterClientFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (ClusterPager, error) {
-				return NewPager[container.ManagedClustersClientListOptions, container.ManagedClustersClientListResponse]{item: &container.ManagedClustersClientListResponse{
-					ManagedClusterListResult: container.ManagedClusterListResult{
-						Value: []*container.ManagedCluster{{}},
-					},
-				}}, nil
-			},
+			name:                 "cluster iteration succeeds",
+			clusterClientFactory: scrappertest.Factory[ClusterPager](scrappertest.NewClusterPager(scrappertest.Items(&container.ManagedCluster{}))),
 			expect: func(t *testing.T, err error) {
 				assert.NoError(t, err)
 			},
 		},
 		{
-			name: "cluster handler fails",
-			clusterClientFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (ClusterPager, error) {
-				return NewPager[container.ManagedClustersClientListOptions, container.ManagedClustersClientListResponse]{item: &container.ManagedClustersClientListResponse{
-					ManagedClusterListResult: container.ManagedClusterListResult{
-						Value: []*container.ManagedCluster{{}},
-					},
-				}}, nil
-			},
-			handlerError: errors.New("failed to Handle cluster"),
+			name:                 "cluster handler fails",
+			clusterClientFactory: scrappertest.Factory[ClusterPager](scrappertest.NewClusterPager(scrappertest.Items(&container.ManagedCluster{}))),
+			handlerError:         errors.New("failed to Handle cluster"),
 			expect: func(t *testing.T, err error) {
 				assert.Error(t, err)
 			},
 		},
 		{
-			name: "cluster iteration fails",
-			clusterClientFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (ClusterPager, error) {
-				return FailPager[container.ManagedClustersClientListOptions, container.ManagedClustersClientListResponse]{}, nil
-			},
+			name:                 "cluster iteration fails",
+			clusterClientFactory: scrappertest.Factory[ClusterPager](scrappertest.NewClusterPager(scrappertest.Fail[container.ManagedCluster](errors.New("failed to iterate")))),
 			expect: func(t *testing.T, err error) {
 				assert.Error(t, err)
 			},
@@ -0,0 +1,281 @@
+package scrapper
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+// ClientFactory creates the client a collector scrapes a subscription with.
+type ClientFactory[C any] func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (C, error)
+
+// Collector describes how a single resource kind is scraped.
+// Adding a resource kind only requires registering a Collector, the scrapper creates its client,
+// schedules it after its dependencies and reports its outcome.
+type Collector[C any] struct {
+	// Kind is the resource kind the collector emits.
+	Kind Kind
+	// DependsOn lists the kinds that must be collected before this one, their resources are available through Collected.
+	DependsOn []Kind
+	// Factory creates the client used by Collect, it can be replaced with WithFactory.
+	// Kinds derived from the resources of the kinds they depend on leave it nil and are handed the zero client.
+	Factory ClientFactory[C]
+	// Collect scrapes the resources of the kind and emits them to the collection.
+	Collect func(ctx context.Context, c *Collection, client C) error
+	// Optional kinds are left out of the scrape unless they are selected with WithKinds.
+	Optional bool
+	// ListByResourceGroup scrapes the resources of a single resource group, it replaces Collect when the scrape is
+	// scoped to resource groups. Scoped scrapes skip kinds without it unless they depend on other kinds.
+	ListByResourceGroup func(ctx context.Context, c *Collection, client C, resourceGroup string) error
+	// Graph lists the kind from Azure Resource Graph in place of Collect when the scrape uses BackendResourceGraph.
+	// Kinds without it are scraped through ARM whatever the backend.
+	Graph *GraphQuery
+	// Empty returns a client listing nothing, it is installed by WithEmptyClient so tests never reach azure.
+	Empty func() C
+}
+
+// registration is a type erased Collector held by the registry.
+type registration interface {
+	kind() Kind
+	dependsOn() []Kind
+	optional() bool
+	newClient(o *Options, sub string, cred az.TokenCredential) (any, error)
+	collect(ctx context.Context, c *Collection, client any) error
+	scopable() bool
+	collectResourceGroup(ctx context.Context, c *Collection, client any, resourceGroup string) error
+	graph() *GraphQuery
+	emptyClient(created func(Kind)) OptionsFunc
+}
+
+func (c Collector[C]) kind() Kind {
+	return c.Kind
+}
+
+func (c Collector[C]) dependsOn() []Kind {
+	return c.DependsOn
+}
+
+func (c Collector[C]) optional() bool {
+	return c.Optional
+}
+
+func (c Collector[C]) newClient(o *Options, sub string, cred az.TokenCredential) (any, error) {
+	factory := c.Factory
+	if f, ok := o.factories[c.Kind].(ClientFactory[C]); ok {
+		factory = f
+	}
+	if factory == nil {
+		return *new(C), nil
+	}
+	return factory(sub, cred, o.clientOptionsFor(c.Kind))
+}
+
+// collect hands Collect the client of the kind, the zero client for kinds without a factory.
+func (c Collector[C]) collect(ctx context.Context, col *Collection, client any) error {
+	typed, _ := client.(C)
+	return c.Collect(ctx, col, typed)
+}
+
+func (c Collector[C]) scopable() bool {
+	return c.ListByResourceGroup != nil
+}
+
+func (c Collector[C]) collectResourceGroup(ctx context.Context, col *Collection, client any, resourceGroup string) error {
+	typed, _ := client.(C)
+	return c.ListByResourceGroup(ctx, col, typed, resourceGroup)
+}
+
+func (c Collector[C]) graph() *GraphQuery {
+	return c.Graph
+}
+
+func (c Collector[C]) emptyClient(created func(Kind)) OptionsFunc {
+	if c.Empty == nil {
+		return nil
+	}
+	client := c.Empty()
+	return WithFactory(c.Kind, func(_ string, _ az.TokenCredential, _ *arm.ClientOptions) (C, error) {
+		if created != nil {
+			created(c.Kind)
+		}
+		return client, nil
+	})
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[Kind]registration{}
+)
+
+// Register adds a collector to the registry, every scrapper created afterwards scrapes its kind.
+// Registering the same kind twice panics.
+func Register[C any](c Collector[C]) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[c.Kind]; ok {
+		panic(fmt.Sprintf("scrapper: collector for %s registered twice", c.Kind))
+	}
+	registry[c.Kind] = c
+}
+
+// Kinds returns every registered resource kind, ordered by name.
+func Kinds() []Kind {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	kinds := make([]Kind, 0, len(registry))
+	for kind := range registry {
+		kinds = append(kinds, kind)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i] < kinds[j] })
+	return kinds
+}
+
+func registered(kind Kind) (registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	r, ok := registry[kind]
+	return r, ok
+}
+
+// WithEmptyClient replaces the client of a registered kind with the Empty client of its collector, created is called
+// with the kind whenever a scrapper creates the client. It returns false when the kind has no Empty client.
+func WithEmptyClient(kind Kind, created func(Kind)) (OptionsFunc, bool) {
+	r, ok := registered(kind)
+	if !ok {
+		return nil, false
+	}
+	opt := r.emptyClient(created)
+	return opt, opt != nil
+}
+
+// WithFactory replaces the client factory of a registered kind.
+func WithFactory[C any](kind Kind, f ClientFactory[C]) OptionsFunc {
+	return func(opt *Options) {
+		opt.factories[kind] = f
+	}
+}
+
+// clientFor returns the client the scrapper created for kind.
+func clientFor[C any](s *Scrapper, kind Kind) C {
+	c, _ := s.clients[kind].(C)
+	return c
+}
+
+// listPages walks every page of pager and hands each item to the page handler.
+func listPages[R any, T any](ctx context.Context, pager *rt.Pager[R], values func(R) []*T, pageHandler pageHandler[T]) error {
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to advance page: %w", err)
+		}
+		if err = processPage(ctx, values(page), pageHandler); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Collection is the state of a single run shared by its collectors.
+// Resources of kinds that other collectors depend on are kept so they can be read back with Collected.
+type Collection struct {
+	scrapper *Scrapper
+	report   *RunReport
+	indexed  map[Kind]bool
+	// graph holds the rows listed from resource graph for the subscription, by kind.
+	graph graphResults
+
+	mu    sync.Mutex
+	found map[Kind][]any
+}
+
+// Scrapper returns the scrapper running the collection.
+func (c *Collection) Scrapper() *Scrapper {
+	return c.scrapper
+}
+
+// Emit wraps a scraped resource in a Record, writes it to the scrapper sink and counts it in the run report.
+// Resources of kinds that are only collected as a dependency of the selected kinds are kept but not written.
+func (c *Collection) Emit(kind Kind, payload any) error {
+	if c.indexed[kind] {
+		c.mu.Lock()
+		c.found[kind] = append(c.found[kind], payload)
+		c.mu.Unlock()
+	}
+	if !c.scrapper.emitted[kind] {
+		return nil
+	}
+
+	err := c.scrapper.sink.Write(Record{
+		Kind:           kind,
+		SubscriptionID: c.scrapper.subscriptionID,
+		RunID:          c.report.RunID,
+		ScrapedAt:      time.Now().UTC(),
+		Payload:        payload,
+	})
+	if err != nil {
+		return err
+	}
+	if kr, ok := c.report.Kinds[kind]; ok {
+		kr.addRecord()
+	}
+	return nil
+}
+
+// Collected returns the resources of a kind emitted so far, kind must be a dependency of the calling collector.
+func Collected[T any](c *Collection, kind Kind) []*T {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	items := make([]*T, 0, len(c.found[kind]))
+	for _, v := range c.found[kind] {
+		if item, ok := v.(*T); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Failed returns the error of the first of the kinds that failed to scrape, so collectors deriving findings from
+// them can give up instead of reporting from incomplete data. It returns nil when every kind succeeded.
+func (c *Collection) Failed(kinds ...Kind) error {
+	for _, kind := range kinds {
+		if kr, ok := c.report.Kinds[kind]; ok {
+			if err := kr.error(); err != nil {
+				return fmt.Errorf("%s is incomplete: %w", kind, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ResourceGroups returns the names of the resource groups in the scope of the scrape, it is only set for scoped scrapes.
+func (c *Collection) ResourceGroups() []string {
+	groups := Collected[resource.ResourceGroup](c, KindResourceGroup)
+	names := make([]string, 0, len(groups))
+	for _, rg := range groups {
+		names = append(names, *rg.Name)
+	}
+	return names
+}
+
+// inScope reports whether a resource group is in the scope of the scrape, every group is when the scrape is not scoped.
+func (c *Collection) inScope(rg *resource.ResourceGroup) bool {
+	return c.scrapper.scope == nil || c.scrapper.scope.Matches(rg)
+}
+
+// emitHandler emits every scraped resource as the given kind.
+func emitHandler[T any](c *Collection, kind Kind) pageHandler[T] {
+	return func(r *T) error {
+		return c.Emit(kind, r)
+	}
+}
@@ -0,0 +1,43 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
+	"context"
+	"testing"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKinds(t *testing.T) {
+	assert.Subset(t, Kinds(), []Kind{KindResourceGroup, KindProvider, KindVirtualNetwork, KindDiskEncryptionSet, KindCluster, KindNodePool})
+}
+
+func TestRegister_Twice(t *testing.T) {
+	assert.Panics(t, func() {
+		Register(Collector[ProvidersPager]{Kind: KindProvider})
+	})
+}
+
+func TestWithFactory(t *testing.T) {
+	var gotSub string
+	factory := func(sub string, _ az.TokenCredential, _ *arm.ClientOptions) (ProvidersPager, error) {
+		gotSub = sub
+		return scrappertest.NewProvidersPager(scrappertest.Items(&resource.Provider{}, &resource.Provider{})), nil
+	}
+
+	s, err := NewScrapper(testCred(), "sub", WithFactory[ProvidersPager](KindProvider, factory))
+	require.NoError(t, err)
+	assert.Equal(t, "sub", gotSub)
+
+	var count int
+	require.NoError(t, s.ListProviders(context.Background(), func(_ *resource.Provider) error {
+		count++
+		return nil
+	}))
+	assert.Equal(t, 2, count)
+}
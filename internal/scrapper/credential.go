@@ -0,0 +1,265 @@
+package scrapper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+)
+
+// Credential sources a CredentialConfig chain can be built from.
+const (
+	CredentialEnvironment       = "environment"
+	CredentialWorkloadIdentity  = "workloadIdentity"
+	CredentialManagedIdentity   = "managedIdentity"
+	CredentialClientCertificate = "clientCertificate"
+	CredentialAzureCLI          = "azureCli"
+)
+
+// CredentialConfig describes the credential chain the scrapper authenticates with, sources are tried in order.
+type CredentialConfig struct {
+	// TenantID is the tenant sources without their own tenant authenticate in.
+	TenantID string             `json:"tenantId,omitempty"`
+	Chain    []CredentialSource `json:"chain"`
+}
+
+// CredentialSource configures a single credential of the chain.
+type CredentialSource struct {
+	Type     string `json:"type"`
+	TenantID string `json:"tenantId,omitempty"`
+	// ClientID is the application of a workload identity or client certificate, or the client id of a user-assigned managed identity.
+	ClientID string `json:"clientId,omitempty"`
+	// ResourceID is the resource id of a user-assigned managed identity, it is used instead of ClientID.
+	ResourceID string `json:"resourceId,omitempty"`
+	// TokenFilePath is the service account token file of a workload identity.
+	TokenFilePath string `json:"tokenFilePath,omitempty"`
+	// CertificatePath is the PEM or PKCS12 file of a client certificate, its password is read from AZURE_CLIENT_CERTIFICATE_PASSWORD.
+	CertificatePath string `json:"certificatePath,omitempty"`
+}
+
+// LoadCredentialConfig reads a credential chain from a json file such as
+//
+//	{"tenantId": "...", "chain": [{"type": "workloadIdentity"}, {"type": "managedIdentity", "clientId": "..."}]}
+func LoadCredentialConfig(path string) (CredentialConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return CredentialConfig{}, fmt.Errorf("failed to read credential config: %w", err)
+	}
+
+	var cfg CredentialConfig
+	if err = json.Unmarshal(data, &cfg); err != nil {
+		return CredentialConfig{}, fmt.Errorf("malformed credential config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// credentialConfigFromEnv reads the credential chain, AZURE_CREDENTIAL_CONFIG is the path of a credential config file
+// and takes precedence over AZURE_CREDENTIAL_CHAIN, a comma separated list of source types configured from the usual
+// AZURE_TENANT_ID, AZURE_CLIENT_ID and AZURE_CLIENT_CERTIFICATE_PATH variables. It returns nil when neither is set.
+func credentialConfigFromEnv() (*CredentialConfig, error) {
+	if path := os.Getenv("AZURE_CREDENTIAL_CONFIG"); path != "" {
+		cfg, err := LoadCredentialConfig(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid AZURE_CREDENTIAL_CONFIG: %w", err)
+		}
+		return &cfg, nil
+	}
+
+	types := splitList(os.Getenv("AZURE_CREDENTIAL_CHAIN"))
+	if len(types) == 0 {
+		return nil, nil
+	}
+	cfg := &CredentialConfig{TenantID: os.Getenv("AZURE_TENANT_ID")}
+	for _, t := range types {
+		cfg.Chain = append(cfg.Chain, CredentialSource{
+			Type:            t,
+			ClientID:        os.Getenv("AZURE_CLIENT_ID"),
+			CertificatePath: os.Getenv("AZURE_CLIENT_CERTIFICATE_PATH"),
+		})
+	}
+	return cfg, nil
+}
+
+// NewCredential builds the chain of credentials described by the config, every source authenticates against the cloud of opts.
+func (c CredentialConfig) NewCredential(opts az.ClientOptions) (*ChainCredential, error) {
+	if len(c.Chain) == 0 {
+		return nil, fmt.Errorf("credential chain is empty")
+	}
+
+	sources := make([]NamedCredential, 0, len(c.Chain))
+	for i, src := range c.Chain {
+		if src.TenantID == "" {
+			src.TenantID = c.TenantID
+		}
+		cred, err := src.newCredential(opts)
+		if err != nil {
+			return nil, fmt.Errorf("credential %d (%s): %w", i, src.Type, err)
+		}
+		sources = append(sources, NamedCredential{Name: src.name(), Credential: cred})
+	}
+	return NewChainCredential(sources...)
+}
+
+func (s CredentialSource) newCredential(opts az.ClientOptions) (az.TokenCredential, error) {
+	switch s.Type {
+	case CredentialEnvironment:
+		return azidentity.NewEnvironmentCredential(&azidentity.EnvironmentCredentialOptions{ClientOptions: opts})
+	case CredentialWorkloadIdentity:
+		return azidentity.NewWorkloadIdentityCredential(&azidentity.WorkloadIdentityCredentialOptions{
+			ClientOptions: opts,
+			ClientID:      s.ClientID,
+			TenantID:      s.TenantID,
+			TokenFilePath: s.TokenFilePath,
+		})
+	case CredentialManagedIdentity:
+		miOpts := &azidentity.ManagedIdentityCredentialOptions{ClientOptions: opts}
+		if s.ResourceID != "" {
+			miOpts.ID = azidentity.ResourceID(s.ResourceID)
+		} else if s.ClientID != "" {
+			miOpts.ID = azidentity.ClientID(s.ClientID)
+		}
+		return azidentity.NewManagedIdentityCredential(miOpts)
+	case CredentialClientCertificate:
+		if s.CertificatePath == "" {
+			return nil, fmt.Errorf("missing certificate path")
+		}
+		data, err := os.ReadFile(s.CertificatePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read certificate: %w", err)
+		}
+		var password []byte
+		if v := os.Getenv("AZURE_CLIENT_CERTIFICATE_PASSWORD"); v != "" {
+			password = []byte(v)
+		}
+		certs, key, err := azidentity.ParseCertificates(data, password)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate: %w", err)
+		}
+		return azidentity.NewClientCertificateCredential(s.TenantID, s.ClientID, certs, key,
+			&azidentity.ClientCertificateCredentialOptions{ClientOptions: opts})
+	case CredentialAzureCLI:
+		return azidentity.NewAzureCLICredential(&azidentity.AzureCLICredentialOptions{TenantID: s.TenantID})
+	}
+	return nil, fmt.Errorf("unknown credential type %q", s.Type)
+}
+
+// name describes the source in logs, without secrets.
+func (s CredentialSource) name() string {
+	var attrs []string
+	if s.ClientID != "" {
+		attrs = append(attrs, "client id "+s.ClientID)
+	}
+	if s.ResourceID != "" {
+		attrs = append(attrs, "resource id "+s.ResourceID)
+	}
+	if s.TenantID != "" {
+		attrs = append(attrs, "tenant "+s.TenantID)
+	}
+	if len(attrs) == 0 {
+		return s.Type
+	}
+	return fmt.Sprintf("%s (%s)", s.Type, strings.Join(attrs, ", "))
+}
+
+// NamedCredential is a source of a ChainCredential, Name is reported once it authenticates.
+type NamedCredential struct {
+	Name       string
+	Credential az.TokenCredential
+}
+
+// ChainCredential is an azidentity.ChainedTokenCredential that remembers which of its sources authenticated.
+type ChainCredential struct {
+	chain *azidentity.ChainedTokenCredential
+
+	mu       sync.Mutex
+	selected string
+}
+
+// NewChainCredential chains the sources, they are tried in order until one returns a token.
+func NewChainCredential(sources ...NamedCredential) (*ChainCredential, error) {
+	c := &ChainCredential{}
+	creds := make([]az.TokenCredential, 0, len(sources))
+	for _, src := range sources {
+		if src.Credential == nil {
+			return nil, fmt.Errorf("credential %s is nil", src.Name)
+		}
+		creds = append(creds, namedCredential{NamedCredential: src, chain: c})
+	}
+
+	chain, err := azidentity.NewChainedTokenCredential(creds, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.chain = chain
+	return c, nil
+}
+
+func (c *ChainCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (az.AccessToken, error) {
+	return c.chain.GetToken(ctx, opts)
+}
+
+// Selected returns the name of the source that authenticated, or an empty string if none did yet.
+func (c *ChainCredential) Selected() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.selected
+}
+
+// namedCredential records itself as the selected source of its chain when it returns a token.
+type namedCredential struct {
+	NamedCredential
+	chain *ChainCredential
+}
+
+func (n namedCredential) GetToken(ctx context.Context, opts policy.TokenRequestOptions) (az.AccessToken, error) {
+	token, err := n.Credential.GetToken(ctx, opts)
+	if err == nil {
+		n.chain.mu.Lock()
+		n.chain.selected = n.Name
+		n.chain.mu.Unlock()
+	}
+	return token, err
+}
+
+// DefaultCredentialConfig returns the chain of azidentity.DefaultAzureCredential, configured from the same
+// AZURE_TENANT_ID and AZURE_CLIENT_ID variables.
+func DefaultCredentialConfig() CredentialConfig {
+	clientID := os.Getenv("AZURE_CLIENT_ID")
+	return CredentialConfig{
+		TenantID: os.Getenv("AZURE_TENANT_ID"),
+		Chain: []CredentialSource{
+			{Type: CredentialEnvironment},
+			{Type: CredentialWorkloadIdentity, ClientID: clientID},
+			{Type: CredentialManagedIdentity, ClientID: clientID},
+			{Type: CredentialAzureCLI},
+		},
+	}
+}
+
+// NewDefaultCredential builds the chain of the config like azidentity.DefaultAzureCredential does,
+// sources the environment does not configure are left out.
+func (c CredentialConfig) NewDefaultCredential(opts az.ClientOptions) (*ChainCredential, error) {
+	var sources []NamedCredential
+	var errs []string
+	for _, src := range c.Chain {
+		if src.TenantID == "" {
+			src.TenantID = c.TenantID
+		}
+		cred, err := src.newCredential(opts)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", src.name(), err))
+			continue
+		}
+		sources = append(sources, NamedCredential{Name: src.name(), Credential: cred})
+	}
+	if len(sources) == 0 {
+		return nil, fmt.Errorf("no credential is configured:\n\t%s", strings.Join(errs, "\n\t"))
+	}
+	return NewChainCredential(sources...)
+}
@@ -0,0 +1,165 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type staticCred struct {
+	err error
+}
+
+func (c staticCred) GetToken(_ context.Context, _ policy.TokenRequestOptions) (az.AccessToken, error) {
+	if c.err != nil {
+		return az.AccessToken{}, c.err
+	}
+	return az.AccessToken{Token: "token", ExpiresOn: time.Now().Add(time.Hour)}, nil
+}
+
+func TestChainCredential(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []NamedCredential
+		want    func(t *testing.T, cred *ChainCredential, err error)
+	}{
+		{
+			name:    "records the source that authenticated",
+			sources: []NamedCredential{{Name: "managedIdentity", Credential: staticCred{}}},
+			want: func(t *testing.T, cred *ChainCredential, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, "managedIdentity", cred.Selected())
+			},
+		},
+		{
+			name:    "nothing is selected when authentication fails",
+			sources: []NamedCredential{{Name: "managedIdentity", Credential: staticCred{err: errors.New("denied")}}},
+			want: func(t *testing.T, cred *ChainCredential, err error) {
+				assert.ErrorContains(t, err, "denied")
+				assert.Empty(t, cred.Selected())
+			},
+		},
+		{
+			name: "a failing source stops the chain",
+			sources: []NamedCredential{
+				{Name: "environment", Credential: staticCred{err: errors.New("misconfigured")}},
+				{Name: "azureCli", Credential: staticCred{}},
+			},
+			want: func(t *testing.T, cred *ChainCredential, err error) {
+				var failed *azidentity.AuthenticationFailedError
+				assert.ErrorAs(t, err, &failed)
+				assert.ErrorContains(t, err, "misconfigured")
+				assert.Empty(t, cred.Selected())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred, err := NewChainCredential(tt.sources...)
+			require.NoError(t, err)
+			assert.Empty(t, cred.Selected())
+
+			_, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{"https://management.azure.com/.default"}})
+			tt.want(t, cred, err)
+		})
+	}
+}
+
+func TestCredentialConfig_NewCredential(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  CredentialConfig
+		wantErr string
+	}{
+		{
+			name: "user-assigned managed identity and azure cli",
+			config: CredentialConfig{TenantID: "tenant", Chain: []CredentialSource{
+				{Type: CredentialManagedIdentity, ClientID: "client"},
+				{Type: CredentialAzureCLI},
+			}},
+		},
+		{
+			name:    "empty chain",
+			wantErr: "credential chain is empty",
+		},
+		{
+			name:    "unknown source",
+			config:  CredentialConfig{Chain: []CredentialSource{{Type: "password"}}},
+			wantErr: `credential 0 (password): unknown credential type "password"`,
+		},
+		{
+			name:    "client certificate without certificate",
+			config:  CredentialConfig{Chain: []CredentialSource{{Type: CredentialClientCertificate, ClientID: "client"}}},
+			wantErr: "credential 0 (clientCertificate): missing certificate path",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cred, err := tt.config.NewCredential(az.ClientOptions{})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, cred)
+		})
+	}
+}
+
+func TestCredentialConfig_NewDefaultCredential(t *testing.T) {
+	identity := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"access_token":"token","expires_on":"%d"}`, time.Now().Add(time.Hour).Unix())
+	}))
+	defer identity.Close()
+	for _, env := range []string{"AZURE_TENANT_ID", "AZURE_CLIENT_ID", "AZURE_CLIENT_SECRET", "AZURE_CLIENT_CERTIFICATE_PATH", "AZURE_USERNAME", "AZURE_FEDERATED_TOKEN_FILE"} {
+		t.Setenv(env, "")
+	}
+	t.Setenv("IDENTITY_ENDPOINT", identity.URL)
+	t.Setenv("IDENTITY_HEADER", "header")
+
+	cfg := DefaultCredentialConfig()
+	assert.Equal(t, []string{CredentialEnvironment, CredentialWorkloadIdentity, CredentialManagedIdentity, CredentialAzureCLI}, sourceTypes(cfg))
+
+	cred, err := cfg.NewDefaultCredential(az.ClientOptions{})
+	require.NoError(t, err)
+	_, err = cred.GetToken(context.Background(), policy.TokenRequestOptions{Scopes: []string{"https://management.azure.com/.default"}})
+	require.NoError(t, err, "unconfigured sources are left out of the chain")
+	assert.Equal(t, CredentialManagedIdentity, cred.Selected())
+}
+
+func sourceTypes(cfg CredentialConfig) []string {
+	var types []string
+	for _, src := range cfg.Chain {
+		types = append(types, src.Type)
+	}
+	return types
+}
+
+func TestLoadCredentialConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credential.json")
+	content := `{"tenantId":"tenant","chain":[{"type":"workloadIdentity"},{"type":"managedIdentity","resourceId":"/identity"}]}`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	cfg, err := LoadCredentialConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, CredentialConfig{TenantID: "tenant", Chain: []CredentialSource{
+		{Type: CredentialWorkloadIdentity},
+		{Type: CredentialManagedIdentity, ResourceID: "/identity"},
+	}}, cfg)
+
+	_, err = LoadCredentialConfig(filepath.Join(t.TempDir(), "missing.json"))
+	assert.ErrorContains(t, err, "failed to read credential config")
+}
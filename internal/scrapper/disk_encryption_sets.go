@@ -2,34 +2,56 @@ package scrapper
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
 	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
 )
 
+const KindDiskEncryptionSet Kind = "diskEncryptionSet"
+
 // DiskEncryptionSetPager used to scrape disk encryption set information
 type DiskEncryptionSetPager interface {
 	NewListPager(options *compute.DiskEncryptionSetsClientListOptions) *rt.Pager[compute.DiskEncryptionSetsClientListResponse]
+	NewListByResourceGroupPager(resourceGroupName string, options *compute.DiskEncryptionSetsClientListByResourceGroupOptions) *rt.Pager[compute.DiskEncryptionSetsClientListByResourceGroupResponse]
 }
 
-type DiskEncryptionSetClientFactory func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (DiskEncryptionSetPager, error)
+type DiskEncryptionSetClientFactory = ClientFactory[DiskEncryptionSetPager]
+
+func init() {
+	Register(Collector[DiskEncryptionSetPager]{
+		Kind: KindDiskEncryptionSet,
+		Factory: func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (DiskEncryptionSetPager, error) {
+			return compute.NewDiskEncryptionSetsClient(subscriptionID, credential, options)
+		},
+		Collect: func(ctx context.Context, c *Collection, _ DiskEncryptionSetPager) error {
+			return c.Scrapper().ListDiskEncryptionSets(ctx, emitHandler[compute.DiskEncryptionSet](c, KindDiskEncryptionSet))
+		},
+		ListByResourceGroup: func(ctx context.Context, c *Collection, _ DiskEncryptionSetPager, resourceGroup string) error {
+			return c.Scrapper().ListDiskEncryptionSetsByResourceGroup(ctx, resourceGroup, emitHandler[compute.DiskEncryptionSet](c, KindDiskEncryptionSet))
+		},
+		Graph: &GraphQuery{
+			Query: graphResources("microsoft.compute/diskencryptionsets"),
+			Collect: func(ctx context.Context, c *Collection, rows []json.RawMessage) error {
+				return graphRows(ctx, rows, emitHandler[compute.DiskEncryptionSet](c, KindDiskEncryptionSet))
+			},
+		},
+	})
+}
 
-func defaultDiskEncryptionSetClientFactory(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (DiskEncryptionSetPager, error) {
-	return compute.NewDiskEncryptionSetsClient(subscriptionID, credential, options)
+func WithDiskEncryptionSetFactory(f DiskEncryptionSetClientFactory) OptionsFunc {
+	return WithFactory(KindDiskEncryptionSet, f)
 }
 
 func (s *Scrapper) ListDiskEncryptionSets(ctx context.Context, pageHandler pageHandler[compute.DiskEncryptionSet]) error {
-	pager := s.diskEncryptionSetClient.NewListPager(nil)
-	for pager.More() {
-		page, err := pager.NextPage(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to advance page: %w", err)
-		}
-		if err = processPage(page.Value, err, pageHandler); err != nil {
-			return err
-		}
-	}
-	return nil
+	pager := clientFor[DiskEncryptionSetPager](s, KindDiskEncryptionSet).NewListPager(nil)
+	return listPages(ctx, pager, func(p compute.DiskEncryptionSetsClientListResponse) []*compute.DiskEncryptionSet { return p.Value }, pageHandler)
+}
+
+func (s *Scrapper) ListDiskEncryptionSetsByResourceGroup(ctx context.Context, resourceGroup string, pageHandler pageHandler[compute.DiskEncryptionSet]) error {
+	pager := clientFor[DiskEncryptionSetPager](s, KindDiskEncryptionSet).NewListByResourceGroupPager(resourceGroup, nil)
+	return listPages(ctx, pager, func(p compute.DiskEncryptionSetsClientListByResourceGroupResponse) []*compute.DiskEncryptionSet {
+		return p.Value
+	}, pageHandler)
 }
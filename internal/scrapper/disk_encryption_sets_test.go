@@ -2,11 +2,10 @@ package scrapper_test
 
 import (
 	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
 	"context"
 	"errors"
 
-	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,37 +20,23 @@ func TestScrapper_ListDiskEncryptionSets(t *testing.T) {
 		expect                func(t *testing.T, err error)
 	}{
 		{
-			name: "disk encryption set iteration succeeds",
-			diskEncryptionFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (DiskEncryptionSetPager, error) {
-				return NewPager[compute.DiskEncryptionSetsClientListOptions, compute.DiskEncryptionSetsClientListResponse]{item: &compute.DiskEncryptionSetsClientListResponse{
-					DiskEncryptionSetList: compute.DiskEncryptionSetList{
-						Value: []*compute.DiskEncryptionSet{{}},
-					},
-				}}, nil
-			},
+			name:                  "disk encryption set iteration succeeds",
+			diskEncryptionFactory: scrappertest.Factory[DiskEncryptionSetPager](scrappertest.NewDiskEncryptionSetPager(scrappertest.Items(&compute.DiskEncryptionSet{}))),
 			expect: func(t *testing.T, err error) {
 				assert.NoError(t, err)
 			},
 		},
 		{
-			name: "disk encryption handler fails",
-			diskEncryptionFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (DiskEncryptionSetPager, error) {
-				return NewPager[compute.DiskEncryptionSetsClientListOptions, compute.DiskEncryptionSetsClientListResponse]{item: &compute.DiskEncryptionSetsClientListResponse{
-					DiskEncryptionSetList: compute.DiskEncryptionSetList{
-						Value: []*compute.DiskEncryptionSet{{}},
-					},
-				}}, nil
-			},
-			handlerError: errors.New("failed to Handle disk encryption set"),
+			name:                  "disk encryption handler fails",
+			diskEncryptionFactory: scrappertest.Factory[DiskEncryptionSetPager](scrappertest.NewDiskEncryptionSetPager(scrappertest.Items(&compute.DiskEncryptionSet{}))),
+			handlerError:          errors.New("failed to Handle disk encryption set"),
 			expect: func(t *testing.T, err error) {
 				assert.Error(t, err)
 			},
 		},
 		{
-			name: "disk encryption set iteration fails",
-			diskEncryptionFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (DiskEncryptionSetPager, error) {
-				return FailPager[compute.DiskEncryptionSetsClientListOptions, compute.DiskEncryptionSetsClientListResponse]{}, nil
-			},
+			name:                  "disk encryption set iteration fails",
+			diskEncryptionFactory: scrappertest.Factory[DiskEncryptionSetPager](scrappertest.NewDiskEncryptionSetPager(scrappertest.Fail[compute.DiskEncryptionSet](errors.New("failed to iterate")))),
 			expect: func(t *testing.T, err error) {
 				assert.Error(t, err)
 			},
@@ -0,0 +1,156 @@
+package scrapper
+
+import (
+	"context"
+	"encoding/json"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+)
+
+const (
+	KindDisk     Kind = "disk"
+	KindSnapshot Kind = "snapshot"
+)
+
+// DiskPager used to scrape managed disk information
+type DiskPager interface {
+	NewListPager(options *compute.DisksClientListOptions) *rt.Pager[compute.DisksClientListResponse]
+	NewListByResourceGroupPager(resourceGroupName string, options *compute.DisksClientListByResourceGroupOptions) *rt.Pager[compute.DisksClientListByResourceGroupResponse]
+}
+
+// SnapshotPager used to scrape snapshot information
+type SnapshotPager interface {
+	NewListPager(options *compute.SnapshotsClientListOptions) *rt.Pager[compute.SnapshotsClientListResponse]
+	NewListByResourceGroupPager(resourceGroupName string, options *compute.SnapshotsClientListByResourceGroupOptions) *rt.Pager[compute.SnapshotsClientListByResourceGroupResponse]
+}
+
+type DiskClientFactory = ClientFactory[DiskPager]
+type SnapshotClientFactory = ClientFactory[SnapshotPager]
+
+func init() {
+	Register(Collector[DiskPager]{
+		Kind: KindDisk,
+		Factory: func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (DiskPager, error) {
+			return compute.NewDisksClient(subscriptionID, credential, options)
+		},
+		Collect: func(ctx context.Context, c *Collection, _ DiskPager) error {
+			return c.Scrapper().ListDisks(ctx, emitHandler[Disk](c, KindDisk))
+		},
+		ListByResourceGroup: func(ctx context.Context, c *Collection, _ DiskPager, resourceGroup string) error {
+			return c.Scrapper().ListDisksByResourceGroup(ctx, resourceGroup, emitHandler[Disk](c, KindDisk))
+		},
+		Graph: &GraphQuery{
+			Query: graphResources("microsoft.compute/disks"),
+			Collect: func(ctx context.Context, c *Collection, rows []json.RawMessage) error {
+				return graphRows(ctx, rows, diskHandler(emitHandler[Disk](c, KindDisk)))
+			},
+		},
+	})
+	Register(Collector[SnapshotPager]{
+		Kind: KindSnapshot,
+		Factory: func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (SnapshotPager, error) {
+			return compute.NewSnapshotsClient(subscriptionID, credential, options)
+		},
+		Collect: func(ctx context.Context, c *Collection, _ SnapshotPager) error {
+			return c.Scrapper().ListSnapshots(ctx, emitHandler[Snapshot](c, KindSnapshot))
+		},
+		ListByResourceGroup: func(ctx context.Context, c *Collection, _ SnapshotPager, resourceGroup string) error {
+			return c.Scrapper().ListSnapshotsByResourceGroup(ctx, resourceGroup, emitHandler[Snapshot](c, KindSnapshot))
+		},
+		Graph: &GraphQuery{
+			Query: graphResources("microsoft.compute/snapshots"),
+			Collect: func(ctx context.Context, c *Collection, rows []json.RawMessage) error {
+				return graphRows(ctx, rows, snapshotHandler(emitHandler[Snapshot](c, KindSnapshot)))
+			},
+		},
+	})
+}
+
+func WithDiskFactory(f DiskClientFactory) OptionsFunc {
+	return WithFactory(KindDisk, f)
+}
+
+func WithSnapshotFactory(f SnapshotClientFactory) OptionsFunc {
+	return WithFactory(KindSnapshot, f)
+}
+
+// Disk is a managed disk along with its encryption, the resource it is attached to, its sku and size.
+type Disk struct {
+	EncryptionType      string        `json:"encryptionType,omitempty"`
+	DiskEncryptionSetID string        `json:"diskEncryptionSetId,omitempty"`
+	AttachedTo          string        `json:"attachedTo,omitempty"`
+	SKU                 string        `json:"sku,omitempty"`
+	SizeGB              int32         `json:"sizeGB,omitempty"`
+	Disk                *compute.Disk `json:"disk"`
+}
+
+// Snapshot is a snapshot along with its encryption, the resource it was taken from, its sku and size.
+type Snapshot struct {
+	EncryptionType      string            `json:"encryptionType,omitempty"`
+	DiskEncryptionSetID string            `json:"diskEncryptionSetId,omitempty"`
+	SourceResourceID    string            `json:"sourceResourceId,omitempty"`
+	SKU                 string            `json:"sku,omitempty"`
+	SizeGB              int32             `json:"sizeGB,omitempty"`
+	Snapshot            *compute.Snapshot `json:"snapshot"`
+}
+
+func (s *Scrapper) ListDisks(ctx context.Context, pageHandler pageHandler[Disk]) error {
+	pager := clientFor[DiskPager](s, KindDisk).NewListPager(nil)
+	return listPages(ctx, pager, func(p compute.DisksClientListResponse) []*compute.Disk { return p.Value }, diskHandler(pageHandler))
+}
+
+func (s *Scrapper) ListDisksByResourceGroup(ctx context.Context, resourceGroup string, pageHandler pageHandler[Disk]) error {
+	pager := clientFor[DiskPager](s, KindDisk).NewListByResourceGroupPager(resourceGroup, nil)
+	return listPages(ctx, pager, func(p compute.DisksClientListByResourceGroupResponse) []*compute.Disk { return p.Value }, diskHandler(pageHandler))
+}
+
+func (s *Scrapper) ListSnapshots(ctx context.Context, pageHandler pageHandler[Snapshot]) error {
+	pager := clientFor[SnapshotPager](s, KindSnapshot).NewListPager(nil)
+	return listPages(ctx, pager, func(p compute.SnapshotsClientListResponse) []*compute.Snapshot { return p.Value }, snapshotHandler(pageHandler))
+}
+
+func (s *Scrapper) ListSnapshotsByResourceGroup(ctx context.Context, resourceGroup string, pageHandler pageHandler[Snapshot]) error {
+	pager := clientFor[SnapshotPager](s, KindSnapshot).NewListByResourceGroupPager(resourceGroup, nil)
+	return listPages(ctx, pager, func(p compute.SnapshotsClientListByResourceGroupResponse) []*compute.Snapshot { return p.Value }, snapshotHandler(pageHandler))
+}
+
+func diskHandler(pageHandler pageHandler[Disk]) pageHandler[compute.Disk] {
+	return func(d *compute.Disk) error {
+		disk := &Disk{AttachedTo: deref(d.ManagedBy), Disk: d}
+		if d.SKU != nil {
+			disk.SKU = string(deref(d.SKU.Name))
+		}
+		if p := d.Properties; p != nil {
+			disk.SizeGB = deref(p.DiskSizeGB)
+			disk.EncryptionType, disk.DiskEncryptionSetID = encryption(p.Encryption)
+		}
+		return pageHandler(disk)
+	}
+}
+
+func snapshotHandler(pageHandler pageHandler[Snapshot]) pageHandler[compute.Snapshot] {
+	return func(s *compute.Snapshot) error {
+		snapshot := &Snapshot{Snapshot: s}
+		if s.SKU != nil {
+			snapshot.SKU = string(deref(s.SKU.Name))
+		}
+		if p := s.Properties; p != nil {
+			snapshot.SizeGB = deref(p.DiskSizeGB)
+			snapshot.EncryptionType, snapshot.DiskEncryptionSetID = encryption(p.Encryption)
+			if p.CreationData != nil {
+				snapshot.SourceResourceID = deref(p.CreationData.SourceResourceID)
+			}
+		}
+		return pageHandler(snapshot)
+	}
+}
+
+func encryption(e *compute.Encryption) (encryptionType string, diskEncryptionSetID string) {
+	if e == nil {
+		return "", ""
+	}
+	return string(deref(e.Type)), deref(e.DiskEncryptionSetID)
+}
@@ -0,0 +1,79 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
+	"context"
+	"testing"
+
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapper_Disks(t *testing.T) {
+	desID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/diskEncryptionSets/des"
+	diskID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/disks/os"
+	vmID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm"
+	disks := scrappertest.NewDiskPager(scrappertest.Items(
+		&compute.Disk{
+			ID:        to(diskID),
+			ManagedBy: to(vmID),
+			SKU:       &compute.DiskSKU{Name: to(compute.DiskStorageAccountTypesPremiumLRS)},
+			Properties: &compute.DiskProperties{
+				DiskSizeGB: to[int32](128),
+				Encryption: &compute.Encryption{
+					Type:                to(compute.EncryptionTypeEncryptionAtRestWithCustomerKey),
+					DiskEncryptionSetID: to(desID),
+				},
+			},
+		},
+		&compute.Disk{ID: to("bare")},
+	))
+	snapshots := scrappertest.NewSnapshotPager(scrappertest.Items(&compute.Snapshot{
+		ID:  to("snap"),
+		SKU: &compute.SnapshotSKU{Name: to(compute.SnapshotStorageAccountTypesStandardLRS)},
+		Properties: &compute.SnapshotProperties{
+			DiskSizeGB:   to[int32](128),
+			CreationData: &compute.CreationData{SourceResourceID: to(diskID)},
+			Encryption:   &compute.Encryption{Type: to(compute.EncryptionTypeEncryptionAtRestWithPlatformKey)},
+		},
+	}))
+
+	sink := NewMemorySink()
+	s, err := NewScrapper(testCred(), "sub",
+		WithKinds(KindDisk, KindSnapshot),
+		WithDiskFactory(scrappertest.Factory[DiskPager](disks)),
+		WithSnapshotFactory(scrappertest.Factory[SnapshotPager](snapshots)),
+		WithSink(sink),
+	)
+	require.NoError(t, err)
+	_, err = s.Run(context.Background())
+	require.NoError(t, err)
+
+	found := map[string]any{}
+	for _, r := range sink.Records() {
+		switch p := r.Payload.(type) {
+		case *Disk:
+			found[*p.Disk.ID] = p
+		case *Snapshot:
+			found[*p.Snapshot.ID] = p
+		default:
+			t.Fatalf("unexpected %s record", r.Kind)
+		}
+	}
+	require.Len(t, found, 3)
+	os := found[diskID].(*Disk)
+	assert.Equal(t, "EncryptionAtRestWithCustomerKey", os.EncryptionType)
+	assert.Equal(t, desID, os.DiskEncryptionSetID)
+	assert.Equal(t, vmID, os.AttachedTo)
+	assert.Equal(t, "Premium_LRS", os.SKU)
+	assert.Equal(t, int32(128), os.SizeGB)
+	assert.Equal(t, &Disk{Disk: &compute.Disk{ID: to("bare")}}, found["bare"])
+	snapshot := found["snap"].(*Snapshot)
+	assert.Equal(t, "EncryptionAtRestWithPlatformKey", snapshot.EncryptionType)
+	assert.Empty(t, snapshot.DiskEncryptionSetID)
+	assert.Equal(t, diskID, snapshot.SourceResourceID)
+	assert.Equal(t, "Standard_LRS", snapshot.SKU)
+	assert.Equal(t, int32(128), snapshot.SizeGB)
+}
@@ -0,0 +1,89 @@
+package scrapper
+
+import (
+	"context"
+	"strings"
+
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+)
+
+const KindEncryptionFinding Kind = "encryptionFinding"
+
+// EncryptionFindingType tells what an EncryptionFinding reports.
+type EncryptionFindingType string
+
+const (
+	// FindingPlatformManagedKey reports a disk that is not encrypted with a customer-managed key.
+	FindingPlatformManagedKey EncryptionFindingType = "platformManagedKey"
+	// FindingUnusedDiskEncryptionSet reports a disk encryption set that no disk or snapshot uses,
+	// resource group scoped scrapes do not report it.
+	FindingUnusedDiskEncryptionSet EncryptionFindingType = "unusedDiskEncryptionSet"
+)
+
+func init() {
+	Register(Collector[any]{
+		Kind:      KindEncryptionFinding,
+		DependsOn: []Kind{KindDisk, KindSnapshot, KindDiskEncryptionSet},
+		Collect: func(ctx context.Context, c *Collection, _ any) error {
+			if err := c.Failed(KindDisk, KindSnapshot, KindDiskEncryptionSet); err != nil {
+				return err
+			}
+			sets := Collected[compute.DiskEncryptionSet](c, KindDiskEncryptionSet)
+			if c.Scrapper().scope != nil {
+				// disks of the resource groups out of scope may use the sets in scope, so none can be told unused
+				sets = nil
+			}
+			return ListEncryptionFindings(ctx,
+				Collected[Disk](c, KindDisk),
+				Collected[Snapshot](c, KindSnapshot),
+				sets,
+				emitHandler[EncryptionFinding](c, KindEncryptionFinding))
+		},
+	})
+}
+
+// EncryptionFinding is a disk encryption gap found from the scraped disks, snapshots and disk encryption sets.
+type EncryptionFinding struct {
+	Finding    EncryptionFindingType `json:"finding"`
+	ResourceID string                `json:"resourceId"`
+	// EncryptionType is the encryption of the disk of a FindingPlatformManagedKey.
+	EncryptionType string `json:"encryptionType,omitempty"`
+}
+
+// ListEncryptionFindings hands a finding to the page handler for every disk without a customer-managed key and
+// every disk encryption set that no disk or snapshot uses.
+func ListEncryptionFindings(ctx context.Context, disks []*Disk, snapshots []*Snapshot, sets []*compute.DiskEncryptionSet, pageHandler pageHandler[EncryptionFinding]) error {
+	used := map[string]bool{}
+	var findings []*EncryptionFinding
+	for _, disk := range disks {
+		used[strings.ToLower(disk.DiskEncryptionSetID)] = true
+		if disk.Disk != nil && disk.Disk.Properties != nil && disk.Disk.Properties.SecurityProfile != nil {
+			used[strings.ToLower(deref(disk.Disk.Properties.SecurityProfile.SecureVMDiskEncryptionSetID))] = true
+		}
+		if !customerManaged(disk.EncryptionType) && disk.Disk != nil && disk.Disk.ID != nil {
+			findings = append(findings, &EncryptionFinding{
+				Finding:        FindingPlatformManagedKey,
+				ResourceID:     *disk.Disk.ID,
+				EncryptionType: disk.EncryptionType,
+			})
+		}
+	}
+	for _, snapshot := range snapshots {
+		used[strings.ToLower(snapshot.DiskEncryptionSetID)] = true
+	}
+	for _, set := range sets {
+		if set.ID != nil && !used[strings.ToLower(*set.ID)] {
+			findings = append(findings, &EncryptionFinding{Finding: FindingUnusedDiskEncryptionSet, ResourceID: *set.ID})
+		}
+	}
+	return processPage(ctx, findings, pageHandler)
+}
+
+// customerManaged reports whether an encryption type uses a customer-managed key, disks without one use platform keys.
+func customerManaged(encryptionType string) bool {
+	switch compute.EncryptionType(encryptionType) {
+	case compute.EncryptionTypeEncryptionAtRestWithCustomerKey, compute.EncryptionTypeEncryptionAtRestWithPlatformAndCustomerKeys:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,130 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
+	"context"
+	"errors"
+	"testing"
+
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListEncryptionFindings(t *testing.T) {
+	desID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/diskEncryptionSets/"
+	cmk := func(id string, des string) *Disk {
+		return &Disk{
+			EncryptionType:      string(compute.EncryptionTypeEncryptionAtRestWithCustomerKey),
+			DiskEncryptionSetID: des,
+			Disk:                &compute.Disk{ID: to(id)},
+		}
+	}
+	tests := []struct {
+		name      string
+		disks     []*Disk
+		snapshots []*Snapshot
+		sets      []*compute.DiskEncryptionSet
+		want      []*EncryptionFinding
+	}{
+		{
+			name:  "customer-managed disks have no finding",
+			disks: []*Disk{cmk("disk", desID+"des")},
+			sets:  []*compute.DiskEncryptionSet{{ID: to(desID + "des")}},
+		},
+		{
+			name: "disks without a customer-managed key",
+			disks: []*Disk{
+				{EncryptionType: string(compute.EncryptionTypeEncryptionAtRestWithPlatformKey), Disk: &compute.Disk{ID: to("platform")}},
+				{Disk: &compute.Disk{ID: to("unknown")}},
+				{EncryptionType: string(compute.EncryptionTypeEncryptionAtRestWithPlatformAndCustomerKeys), Disk: &compute.Disk{ID: to("double")}},
+			},
+			want: []*EncryptionFinding{
+				{Finding: FindingPlatformManagedKey, ResourceID: "platform", EncryptionType: "EncryptionAtRestWithPlatformKey"},
+				{Finding: FindingPlatformManagedKey, ResourceID: "unknown"},
+			},
+		},
+		{
+			name:      "disk encryption sets are matched regardless of case",
+			disks:     []*Disk{cmk("disk", desID+"DES-DISK")},
+			snapshots: []*Snapshot{{DiskEncryptionSetID: desID + "des-snapshot"}},
+			sets: []*compute.DiskEncryptionSet{
+				{ID: to(desID + "des-disk")},
+				{ID: to(desID + "des-snapshot")},
+				{ID: to(desID + "des-unused")},
+			},
+			want: []*EncryptionFinding{{Finding: FindingUnusedDiskEncryptionSet, ResourceID: desID + "des-unused"}},
+		},
+		{
+			name: "confidential disks use their secure vm disk encryption set",
+			disks: []*Disk{{
+				EncryptionType: string(compute.EncryptionTypeEncryptionAtRestWithCustomerKey),
+				Disk: &compute.Disk{ID: to("cvm"), Properties: &compute.DiskProperties{
+					SecurityProfile: &compute.DiskSecurityProfile{SecureVMDiskEncryptionSetID: to(desID + "des-cvm")},
+				}},
+			}},
+			sets: []*compute.DiskEncryptionSet{{ID: to(desID + "des-cvm")}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var findings []*EncryptionFinding
+			err := ListEncryptionFindings(context.Background(), tt.disks, tt.snapshots, tt.sets, func(f *EncryptionFinding) error {
+				findings = append(findings, f)
+				return nil
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, findings)
+		})
+	}
+}
+
+func TestScrapper_EncryptionFindingsWithoutDisks(t *testing.T) {
+	sets := scrappertest.NewDiskEncryptionSetPager(scrappertest.Items(&compute.DiskEncryptionSet{ID: to("des")}))
+
+	sink := NewMemorySink()
+	s, err := NewScrapper(testCred(), "sub", append(scrappertest.Empty(),
+		WithKinds(KindEncryptionFinding),
+		WithDiskFactory(scrappertest.Factory[DiskPager](scrappertest.NewDiskPager(scrappertest.Fail[compute.Disk](errors.New("boom"))))),
+		WithDiskEncryptionSetFactory(scrappertest.Factory[DiskEncryptionSetPager](sets)),
+		WithPartialResults(),
+		WithSink(sink),
+	)...)
+	require.NoError(t, err)
+	report, err := s.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Empty(t, sink.Records(), "an unused disk encryption set cannot be told apart without the disks")
+	assert.ErrorContains(t, report.Kinds[KindEncryptionFinding].Err, "boom")
+}
+
+func TestScrapper_EncryptionFindingsResourceGroupScope(t *testing.T) {
+	groups := scrappertest.NewResourceGroupsPager(scrappertest.Items(&resource.ResourceGroup{Name: to("rg-a")}))
+	disks := scrappertest.NewDiskPager().ResourceGroup("rg-a", scrappertest.Items(&compute.Disk{ID: to("disk-a")}))
+	sets := scrappertest.NewDiskEncryptionSetPager().
+		ResourceGroup("rg-a", scrappertest.Items(&compute.DiskEncryptionSet{ID: to("des-a")}))
+	scope, err := ParseResourceGroupScope("rg-a", "")
+	require.NoError(t, err)
+
+	sink := NewMemorySink()
+	s, err := NewScrapper(testCred(), "sub", append(scrappertest.Empty(),
+		WithKinds(KindEncryptionFinding),
+		WithResourceGroupsFactory(scrappertest.Factory[ResourceGroupsPager](groups)),
+		WithDiskFactory(scrappertest.Factory[DiskPager](disks)),
+		WithDiskEncryptionSetFactory(scrappertest.Factory[DiskEncryptionSetPager](sets)),
+		WithResourceGroupScope(scope),
+		WithSink(sink),
+	)...)
+	require.NoError(t, err)
+	_, err = s.Run(context.Background())
+	require.NoError(t, err)
+
+	var findings []EncryptionFinding
+	for _, r := range sink.Records() {
+		findings = append(findings, *r.Payload.(*EncryptionFinding))
+	}
+	assert.Equal(t, []EncryptionFinding{{Finding: FindingPlatformManagedKey, ResourceID: "disk-a"}}, findings,
+		"disks out of scope may use des-a")
+}
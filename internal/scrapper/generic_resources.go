@@ -0,0 +1,101 @@
+package scrapper
+
+import (
+	"context"
+	"encoding/json"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+const KindGenericResource Kind = "genericResource"
+
+// resourceTimestamps is the $expand of generic resource listings that adds their creation and last change times.
+const resourceTimestamps = "createdTime,changedTime"
+
+// genericResourceColumns are the columns of the resources table the ARM listing returns, without the properties.
+const genericResourceColumns = "id, name, type, kind, location, extendedLocation, managedBy, sku, plan, tags, identity, subscriptionId, resourceGroup"
+
+// GenericResourcePager used to scrape every resource of a subscription, whatever its type
+type GenericResourcePager interface {
+	NewListPager(options *resource.ClientListOptions) *rt.Pager[resource.ClientListResponse]
+	NewListByResourceGroupPager(resourceGroupName string, options *resource.ClientListByResourceGroupOptions) *rt.Pager[resource.ClientListByResourceGroupResponse]
+}
+
+type GenericResourceClientFactory = ClientFactory[GenericResourcePager]
+
+func init() {
+	Register(Collector[GenericResourcePager]{
+		Kind:     KindGenericResource,
+		Optional: true,
+		Factory: func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (GenericResourcePager, error) {
+			return resource.NewClient(subscriptionID, credential, options)
+		},
+		Collect: func(ctx context.Context, c *Collection, _ GenericResourcePager) error {
+			return c.Scrapper().ListGenericResources(ctx, emitHandler[resource.GenericResourceExpanded](c, KindGenericResource))
+		},
+		ListByResourceGroup: func(ctx context.Context, c *Collection, _ GenericResourcePager, resourceGroup string) error {
+			return c.Scrapper().ListGenericResourcesByResourceGroup(ctx, resourceGroup, emitHandler[resource.GenericResourceExpanded](c, KindGenericResource))
+		},
+		Graph: &GraphQuery{
+			Query: "resources | project " + genericResourceColumns,
+			Collect: func(ctx context.Context, c *Collection, rows []json.RawMessage) error {
+				return graphRows(ctx, rows, func(r *resource.GenericResourceExpanded) error {
+					return c.Emit(KindGenericResource, genericGraphResource(r))
+				})
+			},
+			// resource graph does not hold the creation and change times of resources
+			NeedsARM: func(o *Options) bool {
+				return o.kindConfigs[KindGenericResource].expand
+			},
+		},
+	})
+}
+
+func WithGenericResourceFactory(f GenericResourceClientFactory) OptionsFunc {
+	return WithFactory(KindGenericResource, f)
+}
+
+// WithResourceTimestamps reads the creation and last change times of generic resources.
+func WithResourceTimestamps() OptionsFunc {
+	return func(opt *Options) {
+		opt.configureKind(func(c *kindConfig) { c.expand = true }, KindGenericResource)
+	}
+}
+
+func (s *Scrapper) ListGenericResources(ctx context.Context, pageHandler pageHandler[resource.GenericResourceExpanded]) error {
+	var options *resource.ClientListOptions
+	if s.kindConfig(KindGenericResource).expand {
+		expand := resourceTimestamps
+		options = &resource.ClientListOptions{Expand: &expand}
+	}
+	pager := clientFor[GenericResourcePager](s, KindGenericResource).NewListPager(options)
+	return listPages(ctx, pager, func(p resource.ClientListResponse) []*resource.GenericResourceExpanded { return p.Value }, pageHandler)
+}
+
+func (s *Scrapper) ListGenericResourcesByResourceGroup(ctx context.Context, resourceGroup string, pageHandler pageHandler[resource.GenericResourceExpanded]) error {
+	var options *resource.ClientListByResourceGroupOptions
+	if s.kindConfig(KindGenericResource).expand {
+		expand := resourceTimestamps
+		options = &resource.ClientListByResourceGroupOptions{Expand: &expand}
+	}
+	pager := clientFor[GenericResourcePager](s, KindGenericResource).NewListByResourceGroupPager(resourceGroup, options)
+	return listPages(ctx, pager, func(p resource.ClientListByResourceGroupResponse) []*resource.GenericResourceExpanded { return p.Value }, pageHandler)
+}
+
+// genericGraphResource clears the columns resource graph returns empty for resources without them,
+// which the ARM listing leaves out.
+func genericGraphResource(r *resource.GenericResourceExpanded) *resource.GenericResourceExpanded {
+	if r.Kind != nil && *r.Kind == "" {
+		r.Kind = nil
+	}
+	if r.ManagedBy != nil && *r.ManagedBy == "" {
+		r.ManagedBy = nil
+	}
+	if len(r.Tags) == 0 {
+		r.Tags = nil
+	}
+	return r
+}
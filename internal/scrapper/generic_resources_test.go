@@ -0,0 +1,112 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
+	"context"
+	"errors"
+	"testing"
+
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapper_ListGenericResources(t *testing.T) {
+	tests := []struct {
+		name         string
+		pager        *scrappertest.GenericResourcePager
+		options      []OptionsFunc
+		handlerError error
+		expect       func(t *testing.T, pager *scrappertest.GenericResourcePager, resources []*resource.GenericResourceExpanded, err error)
+	}{
+		{
+			name: "timestamps are not expanded by default",
+			pager: scrappertest.NewGenericResourcePager(
+				scrappertest.Items(&resource.GenericResourceExpanded{ID: to("vault"), Type: to("Microsoft.KeyVault/vaults")}),
+				scrappertest.Items(&resource.GenericResourceExpanded{ID: to("account"), Kind: to("StorageV2")}),
+			),
+			expect: func(t *testing.T, pager *scrappertest.GenericResourcePager, resources []*resource.GenericResourceExpanded, err error) {
+				require.NoError(t, err)
+				require.Len(t, resources, 2)
+				assert.Equal(t, "Microsoft.KeyVault/vaults", *resources[0].Type)
+				assert.Equal(t, "StorageV2", *resources[1].Kind)
+				require.Len(t, pager.Calls(), 1)
+				assert.Nil(t, pager.Calls()[0].Options)
+			},
+		},
+		{
+			name:    "timestamps are expanded",
+			pager:   scrappertest.NewGenericResourcePager(scrappertest.Items(&resource.GenericResourceExpanded{ID: to("vault")})),
+			options: []OptionsFunc{WithResourceTimestamps()},
+			expect: func(t *testing.T, pager *scrappertest.GenericResourcePager, resources []*resource.GenericResourceExpanded, err error) {
+				require.NoError(t, err)
+				require.Len(t, pager.Calls(), 1)
+				require.NotNil(t, pager.Calls()[0].Options)
+				assert.Equal(t, "createdTime,changedTime", *pager.Calls()[0].Options.Expand)
+			},
+		},
+		{
+			name:         "handler fails",
+			pager:        scrappertest.NewGenericResourcePager(scrappertest.Items(&resource.GenericResourceExpanded{ID: to("vault")})),
+			handlerError: errors.New("failed to Handle resource"),
+			expect: func(t *testing.T, pager *scrappertest.GenericResourcePager, resources []*resource.GenericResourceExpanded, err error) {
+				assert.ErrorContains(t, err, "failed to Handle resource")
+			},
+		},
+		{
+			name:  "iteration fails",
+			pager: scrappertest.NewGenericResourcePager(scrappertest.Fail[resource.GenericResourceExpanded](errors.New("boom"))),
+			expect: func(t *testing.T, pager *scrappertest.GenericResourcePager, resources []*resource.GenericResourceExpanded, err error) {
+				assert.ErrorContains(t, err, "boom")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]OptionsFunc{
+				WithKinds(KindGenericResource),
+				WithGenericResourceFactory(scrappertest.Factory[GenericResourcePager](tt.pager)),
+			}, tt.options...)
+			scraper, err := NewScrapper(testCred(), "not-important", options...)
+			require.NoError(t, err)
+
+			var resources []*resource.GenericResourceExpanded
+			err = scraper.ListGenericResources(context.Background(), func(r *resource.GenericResourceExpanded) error {
+				resources = append(resources, r)
+				return tt.handlerError
+			})
+			tt.expect(t, tt.pager, resources, err)
+		})
+	}
+}
+
+func TestScrapper_GenericResourcesScoped(t *testing.T) {
+	groups := scrappertest.NewResourceGroupsPager(scrappertest.Items(
+		&resource.ResourceGroup{Name: to("rg-a")},
+		&resource.ResourceGroup{Name: to("other")},
+	))
+	resources := scrappertest.NewGenericResourcePager(scrappertest.Items(&resource.GenericResourceExpanded{ID: to("everywhere")})).
+		ResourceGroup("rg-a", scrappertest.Items(&resource.GenericResourceExpanded{ID: to("in-rg-a")}))
+
+	scope, err := ParseResourceGroupScope("rg-*", "")
+	require.NoError(t, err)
+	sink := NewMemorySink()
+	s, err := NewScrapper(testCred(), "sub",
+		WithKinds(KindGenericResource),
+		WithResourceTimestamps(),
+		WithResourceGroupsFactory(scrappertest.Factory[ResourceGroupsPager](groups)),
+		WithGenericResourceFactory(scrappertest.Factory[GenericResourcePager](resources)),
+		WithResourceGroupScope(scope),
+		WithSink(sink),
+	)
+	require.NoError(t, err)
+	_, err = s.Run(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, sink.Records(), 1)
+	assert.Equal(t, "in-rg-a", *sink.Records()[0].Payload.(*resource.GenericResourceExpanded).ID)
+	assert.Empty(t, resources.Calls(), "subscription wide listing")
+	require.Len(t, resources.GroupCalls(), 1)
+	assert.Equal(t, "createdTime,changedTime", *resources.GroupCalls()[0].Options.Expand)
+}
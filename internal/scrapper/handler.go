@@ -1,63 +1,431 @@
 package scrapper
 
 import (
+	"azure-scrapper/internal/recorder"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
 )
 
-func Handle(w http.ResponseWriter, _ *http.Request) {
+// Handle serves the scrapper function using the default azure credential and sdk clients.
+func Handle(w http.ResponseWriter, r *http.Request) {
+	NewHandler()(w, r)
+}
+
+// NewHandler creates the http triggered scrapper function handler,
+// the option functions are applied on top of the ones read from env.
+func NewHandler(opts ...OptionsFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inv := newInvocation()
+
+		params, err := scrapeParameters(r)
+		if err != nil {
+			inv.fail(http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+			writeJSON(w, inv)
+			return
+		}
+
+		inv.scrape(r.Context(), params, opts)
+		writeJSON(w, inv)
+	}
+}
+
+// HandleTimer serves the timer triggered scrapper function using the default azure credential and sdk clients.
+func HandleTimer(w http.ResponseWriter, r *http.Request) {
+	NewTimerHandler()(w, r)
+}
+
+// NewTimerHandler creates the timer triggered scrapper function handler,
+// the option functions are applied on top of the ones read from env.
+func NewTimerHandler(opts ...OptionsFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		inv := newInvocation()
+
+		req, err := DecodeInvokeRequest(r)
+		if err != nil {
+			inv.fail(http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+			writeTimerJSON(w, inv)
+			return
+		}
+		timer, err := req.TimerInfo("timer")
+		if err != nil {
+			inv.fail(http.StatusBadRequest, fmt.Sprintf("invalid request: %v", err))
+			writeTimerJSON(w, inv)
+			return
+		}
+		if timer != nil {
+			inv.log(timer.String())
+		}
+
+		inv.scrape(r.Context(), ScrapeParameters{Format: FormatSummary}, opts)
+		writeTimerJSON(w, inv)
+	}
+}
+
+// invocation accumulates the response of a single function invocation.
+type invocation struct {
+	resp    InvokeResponse
+	summary *Summary
+	start   time.Time
+}
+
+func newInvocation() *invocation {
 	resp := InvokeResponse{
-		Outputs:     map[string]resData{"res": {}},
+		Outputs:     map[string]interface{}{},
 		Logs:        []string{},
 		ReturnValue: "",
 	}
+	return &invocation{
+		resp:    resp,
+		summary: &Summary{Subscriptions: []*RunReport{}},
+		start:   time.Now(),
+	}
+}
+
+func (inv *invocation) log(msg string) {
+	inv.resp.Logs = append(inv.resp.Logs, msg)
+}
+
+// fail logs msg, records it in the summary and sets the status code of the invocation.
+func (inv *invocation) fail(code int, msg string) {
+	inv.log(msg)
+	inv.summary.Errors = append(inv.summary.Errors, msg)
+	inv.summary.Status = code
+}
+
+// scrape runs the scrapper with the invocation parameters and records the outcome in the summary.
+func (inv *invocation) scrape(ctx context.Context, params ScrapeParameters, opts []OptionsFunc) {
+	subs, err := requestedSubscriptions(params.Subscriptions)
+	if err != nil {
+		inv.fail(http.StatusForbidden, fmt.Sprintf("invalid request: %v", err))
+		return
+	}
+	envOpts, err := optionsFromEnv()
+	if err != nil {
+		inv.fail(http.StatusInternalServerError, fmt.Sprintf("invalid configuration: %v", err))
+		return
+	}
+	scrapeOpts := append(envOpts, opts...)
+	if len(params.Include) > 0 {
+		scrapeOpts = append(scrapeOpts, WithKinds(params.Include...))
+	}
+	if len(params.Exclude) > 0 {
+		scrapeOpts = append(scrapeOpts, WithoutKinds(params.Exclude...))
+	}
 
-	sub := os.Getenv("AZURE_SUBSCRIPTION")
-	cred, err := azidentity.NewDefaultAzureCredential(nil)
+	sinks := []Sink{resolveOptions(scrapeOpts...).sink}
+	var records *MemorySink
+	if params.Format == FormatRecords {
+		records = NewMemorySink()
+		sinks = append(sinks, records)
+	}
+	blobOutput := os.Getenv("SCRAPPER_BLOB_OUTPUT")
+	var blob bytes.Buffer
+	if blobOutput != "" {
+		sinks = append(sinks, NewWriterSink(&blob))
+	}
+	scrapeOpts = append(scrapeOpts, WithSink(NewMultiSink(sinks...)))
+
+	cloudCfg, err := cloudFromEnv()
 	if err != nil {
-		resp.Logs = append(resp.Logs, fmt.Sprintf("failed to obtain a credential: %v", err))
-		writeJSON(w, resp, http.StatusInternalServerError)
+		inv.fail(http.StatusInternalServerError, fmt.Sprintf("invalid configuration: %v", err))
 		return
 	}
+	var credOpts az.ClientOptions
+	if cloudCfg != nil {
+		credOpts.Cloud = *cloudCfg
+		scrapeOpts = append(scrapeOpts, WithCloud(*cloudCfg))
+	}
 
-	scrapper, err := NewScrapper(cred, sub)
+	rec, err := recorderFromEnv()
+	if err != nil {
+		inv.fail(http.StatusInternalServerError, fmt.Sprintf("invalid configuration: %v", err))
+		return
+	}
+	if rec != nil {
+		scrapeOpts = append(scrapeOpts, WithTransport(rec))
+		defer func() {
+			if err := rec.Save(); err != nil {
+				inv.log(fmt.Sprintf("failed to save the recorded scrape: %v", err))
+			}
+		}()
+	}
+
+	var cred *ChainCredential
+	if rec != nil && rec.Mode() == recorder.ModeReplay {
+		cred, err = NewChainCredential(NamedCredential{Name: "replayed cassette", Credential: recorder.Credential()})
+	} else {
+		cred, err = credentialFromEnv(credOpts)
+	}
+	if err != nil {
+		inv.fail(http.StatusInternalServerError, fmt.Sprintf("failed to obtain a credential: %v", err))
+		return
+	}
+
+	scrapper, err := NewMultiScrapper(cred, subs, append(scrapeOpts, WithPartialResults())...)
+	if err != nil {
+		inv.fail(http.StatusInternalServerError, fmt.Sprintf("unable to initialize scrapper: %v", err))
+		return
+	}
+
+	reports, err := scrapper.Run(ctx)
+	if selected := cred.Selected(); selected != "" {
+		inv.log(fmt.Sprintf("authenticated with %s", selected))
+	}
+	inv.summary.Duration = time.Since(inv.start).String()
+	inv.summary.Subscriptions = append(inv.summary.Subscriptions, reports...)
+	if records != nil {
+		inv.summary.Records = records.Records()
+	}
+	if blobOutput != "" {
+		inv.resp.Outputs[blobOutput] = blob.String()
+	}
 	if err != nil {
-		resp.Logs = append(resp.Logs, fmt.Sprintf("unable to initialize scrapper: %v", err))
-		writeJSON(w, resp, http.StatusInternalServerError)
+		var failures SubscriptionErrors
+		if errors.As(err, &failures) {
+			for _, sub := range failures.subscriptions() {
+				msg := fmt.Sprintf("scrapper failed for subscription %s: %v", sub, failures[sub])
+				inv.log(msg)
+				inv.summary.Errors = append(inv.summary.Errors, msg)
+			}
+			inv.fail(http.StatusInternalServerError, "scrapper failed for one or more subscriptions")
+			return
+		}
+		inv.fail(http.StatusInternalServerError, fmt.Sprintf("scrapper failed: %v", err))
 		return
 	}
 
-	if err = scrapper.Run(); err != nil {
-		resp.Logs = append(resp.Logs, fmt.Sprintf("scrapper failed: %v", err))
-		writeJSON(w, resp, http.StatusInternalServerError)
+	failed := false
+	for _, report := range reports {
+		for _, kr := range report.sortedKinds() {
+			if err := kr.error(); err != nil {
+				failed = true
+				inv.log(fmt.Sprintf("scrapper failed for subscription %s: %v", report.SubscriptionID, err))
+			}
+		}
+	}
+	if failed && os.Getenv("SCRAPPER_ALLOW_PARTIAL") != "true" {
+		inv.fail(http.StatusInternalServerError, "scrapper failed for one or more resource kinds")
 		return
 	}
 
-	writeJSON(w, resp, http.StatusOK)
-	return
+	inv.summary.Status = http.StatusOK
+}
+
+// scrapeParameters decodes the invoke payload posted by the functions host and reads the parameters of the http trigger.
+func scrapeParameters(r *http.Request) (ScrapeParameters, error) {
+	req, err := DecodeInvokeRequest(r)
+	if err != nil {
+		return ScrapeParameters{}, err
+	}
+	trigger, err := req.HTTPRequest("req")
+	if err != nil {
+		return ScrapeParameters{}, err
+	}
+	if trigger == nil {
+		trigger = &HTTPTriggerRequest{}
+	}
+	return trigger.ScrapeParameters()
+}
+
+// credentialFromEnv builds the credential chain configured in env, falling back to the chain of the default azure credential.
+func credentialFromEnv(opts az.ClientOptions) (*ChainCredential, error) {
+	cfg, err := credentialConfigFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if cfg != nil {
+		return cfg.NewCredential(opts)
+	}
+
+	return DefaultCredentialConfig().NewDefaultCredential(opts)
+}
+
+// recorderFromEnv reads where the ARM exchanges of the scrape are recorded, SCRAPPER_RECORD is the path of the cassette
+// every exchange is recorded into and SCRAPPER_REPLAY the path of a cassette served instead of ARM. It returns nil when neither is set.
+func recorderFromEnv() (*recorder.Recorder, error) {
+	record, replay := os.Getenv("SCRAPPER_RECORD"), os.Getenv("SCRAPPER_REPLAY")
+	switch {
+	case record != "" && replay != "":
+		return nil, fmt.Errorf("SCRAPPER_RECORD and SCRAPPER_REPLAY are mutually exclusive")
+	case record != "":
+		return recorder.New(record, recorder.ModeRecord, nil)
+	case replay != "":
+		rec, err := recorder.New(replay, recorder.ModeReplay, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCRAPPER_REPLAY: %w", err)
+		}
+		return rec, nil
+	}
+	return nil, nil
+}
+
+// subscriptionsFromEnv reads the comma separated AZURE_SUBSCRIPTION list, an empty list means every visible subscription.
+func subscriptionsFromEnv() []string {
+	return splitList(os.Getenv("AZURE_SUBSCRIPTION"))
+}
+
+// requestedSubscriptions returns the subscriptions requested by the trigger, or the AZURE_SUBSCRIPTION list when none is.
+// Requested subscriptions must be in AZURE_SUBSCRIPTION or in the comma separated SCRAPPER_ALLOWED_SUBSCRIPTIONS list,
+// so callers cannot read the inventory of every subscription the function identity has access to.
+func requestedSubscriptions(requested []string) ([]string, error) {
+	configured := subscriptionsFromEnv()
+	if len(requested) == 0 {
+		return configured, nil
+	}
+
+	allowed := map[string]bool{}
+	for _, sub := range append(configured, splitList(os.Getenv("SCRAPPER_ALLOWED_SUBSCRIPTIONS"))...) {
+		allowed[strings.ToLower(sub)] = true
+	}
+	for _, sub := range requested {
+		if !allowed[strings.ToLower(sub)] {
+			return nil, fmt.Errorf("subscription %s is not allowed", sub)
+		}
+	}
+	return requested, nil
+}
+
+// optionsFromEnv reads the scrape timeouts, SCRAPPER_TIMEOUT is a duration such as 2m and
+// SCRAPPER_KIND_TIMEOUTS a comma separated list of kind=duration pairs such as managedCluster=1m,nodePool=5m.
+// SCRAPPER_INCLUDE_KINDS and SCRAPPER_EXCLUDE_KINDS are comma separated lists of resource kinds to scrape or leave out.
+// SCRAPPER_RESOURCE_GROUPS, resource group names and globs, and SCRAPPER_RESOURCE_GROUP_TAGS, a tag selector such as
+// team=payments,env, scope the scrape to the matching resource groups.
+// Requests are throttled unless SCRAPPER_THROTTLE is false, power states are read when SCRAPPER_INSTANCE_VIEW is true
+// and the creation and change times of generic resources when SCRAPPER_RESOURCE_TIMESTAMPS is true.
+// SCRAPPER_BACKEND is arm, the default, or resourceGraph to list the kinds it supports with Azure Resource Graph.
+func optionsFromEnv() ([]OptionsFunc, error) {
+	var opts []OptionsFunc
+	if os.Getenv("SCRAPPER_THROTTLE") != "false" {
+		opts = append(opts, WithThrottling(DefaultThrottleOptions()))
+	}
+	if os.Getenv("SCRAPPER_INSTANCE_VIEW") == "true" {
+		opts = append(opts, WithInstanceView())
+	}
+	if os.Getenv("SCRAPPER_RESOURCE_TIMESTAMPS") == "true" {
+		opts = append(opts, WithResourceTimestamps())
+	}
+	if val, ok := os.LookupEnv("SCRAPPER_TIMEOUT"); ok {
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCRAPPER_TIMEOUT: %w", err)
+		}
+		opts = append(opts, WithTimeout(d))
+	}
+
+	for _, pair := range strings.Split(os.Getenv("SCRAPPER_KIND_TIMEOUTS"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+		kind, val, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid SCRAPPER_KIND_TIMEOUTS entry %q", pair)
+		}
+		kinds, err := ParseKinds([]string{kind})
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCRAPPER_KIND_TIMEOUTS entry %q: %w", pair, err)
+		}
+		d, err := time.ParseDuration(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCRAPPER_KIND_TIMEOUTS entry %q: %w", pair, err)
+		}
+		opts = append(opts, WithKindTimeout(kinds[0], d))
+	}
+
+	if val := os.Getenv("SCRAPPER_INCLUDE_KINDS"); val != "" {
+		kinds, err := ParseKinds(splitList(val))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCRAPPER_INCLUDE_KINDS: %w", err)
+		}
+		opts = append(opts, WithKinds(kinds...))
+	}
+	if val := os.Getenv("SCRAPPER_EXCLUDE_KINDS"); val != "" {
+		kinds, err := ParseKinds(splitList(val))
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCRAPPER_EXCLUDE_KINDS: %w", err)
+		}
+		opts = append(opts, WithoutKinds(kinds...))
+	}
+
+	if val := os.Getenv("SCRAPPER_BACKEND"); val != "" {
+		backend, err := ParseBackend(val)
+		if err != nil {
+			return nil, fmt.Errorf("invalid SCRAPPER_BACKEND: %w", err)
+		}
+		opts = append(opts, WithBackend(backend))
+	}
+
+	groups, tags := os.Getenv("SCRAPPER_RESOURCE_GROUPS"), os.Getenv("SCRAPPER_RESOURCE_GROUP_TAGS")
+	if groups != "" || tags != "" {
+		scope, err := ParseResourceGroupScope(groups, tags)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resource group scope: %w", err)
+		}
+		opts = append(opts, WithResourceGroupScope(scope))
+	}
+	return opts, nil
 }
 
 type resData = map[string]interface{}
 
+// InvokeResponse is the payload a custom handler returns to the functions host,
+// Outputs holds the value of every output binding keyed by binding name.
 type InvokeResponse struct {
-	Outputs     map[string]resData
+	Outputs     map[string]interface{}
 	Logs        []string
 	ReturnValue interface{}
 }
 
-func writeJSON(w http.ResponseWriter, result InvokeResponse, code int) {
-	result.Outputs["res"]["statuscode"] = code
-	result.Outputs["res"]["headers"] = map[string]string{"Content-Type": "application/json"}
-	result.Outputs["res"]["body"] = fmt.Sprintf("{\"status\":\"%v\"}", code)
+// Summary describes the outcome of an invocation, it is returned as both the http body and the function return value,
+// Records are only returned in the http body.
+type Summary struct {
+	Status        int          `json:"status"`
+	Duration      string       `json:"duration,omitempty"`
+	Subscriptions []*RunReport `json:"subscriptions"`
+	Errors        []string     `json:"errors,omitempty"`
+	Records       []Record     `json:"records,omitempty"`
+}
+
+// writeJSON writes the invocation response, the summary is returned through the http output binding and the return value,
+// records only through the http output binding so they are not sent twice.
+func writeJSON(w http.ResponseWriter, inv *invocation) {
+	body, _ := json.Marshal(inv.summary)
+
+	result := inv.resp
+	result.Outputs["res"] = resData{
+		"statuscode": inv.summary.Status,
+		"headers":    map[string]string{"Content-Type": "application/json"},
+		"body":       string(body),
+	}
+	summary := *inv.summary
+	summary.Records = nil
+	result.ReturnValue = &summary
+
+	writeResponse(w, result, http.StatusOK)
+}
+
+// writeTimerJSON writes the invocation response of a timer trigger, which has no http output binding,
+// so failures are reported to the functions host through the status code.
+func writeTimerJSON(w http.ResponseWriter, inv *invocation) {
+	result := inv.resp
+	result.ReturnValue = inv.summary
+	writeResponse(w, result, inv.summary.Status)
+}
 
+func writeResponse(w http.ResponseWriter, result InvokeResponse, code int) {
 	responseJson, _ := json.Marshal(result)
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
 	_, err := w.Write(responseJson)
 	if err != nil {
 		log.Printf("failed to write response: %v", err)
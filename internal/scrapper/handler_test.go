@@ -0,0 +1,268 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandle(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		body string
+		want func(t *testing.T, resp InvokeResponse, summary Summary)
+	}{
+		{
+			name: "successful scrape returns the report",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a"},
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusOK, summary.Status)
+				assert.NotEmpty(t, summary.Duration)
+				assert.Empty(t, summary.Errors)
+				require.Len(t, summary.Subscriptions, 1)
+				assert.Equal(t, "sub-a", summary.Subscriptions[0].SubscriptionID)
+				assert.Equal(t, 1, summary.Subscriptions[0].Kinds[KindCluster].Records)
+				assert.Equal(t, float64(http.StatusOK), resp.Outputs["res"].(map[string]any)["statuscode"])
+				assert.Equal(t, float64(http.StatusOK), resp.ReturnValue.(map[string]any)["status"])
+			},
+		},
+		{
+			name: "failing kind fails the invocation",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a,bad"},
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusInternalServerError, summary.Status)
+				assert.Len(t, summary.Subscriptions, 2)
+				assert.Contains(t, summary.Errors, "scrapper failed for one or more resource kinds")
+				assert.Contains(t, resp.Logs[0], "scrapper failed for subscription bad")
+			},
+		},
+		{
+			name: "partial results can be allowed",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a,bad", "SCRAPPER_ALLOW_PARTIAL": "true"},
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusOK, summary.Status)
+				assert.Len(t, summary.Subscriptions, 2)
+				assert.NotEmpty(t, resp.Logs)
+			},
+		},
+		{
+			name: "subscriptions and records from the trigger request",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "bad,sub-b"},
+			body: `{"Data":{"req":{"Query":{"subscription":"sub-b","format":"records"}}}}`,
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusOK, summary.Status)
+				require.Len(t, summary.Subscriptions, 1)
+				assert.Equal(t, "sub-b", summary.Subscriptions[0].SubscriptionID)
+				require.Len(t, summary.Records, 1)
+				assert.Equal(t, KindCluster, summary.Records[0].Kind)
+				assert.NotContains(t, resp.ReturnValue, "records", "records are only returned in the http body")
+				assert.Equal(t, float64(http.StatusOK), resp.ReturnValue.(map[string]any)["status"])
+			},
+		},
+		{
+			name: "subscriptions from the allow list",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "bad", "SCRAPPER_ALLOWED_SUBSCRIPTIONS": "sub-a,SUB-B"},
+			body: `{"Data":{"req":{"Query":{"subscription":"sub-a,sub-b"}}}}`,
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusOK, summary.Status)
+				assert.Len(t, summary.Subscriptions, 2)
+			},
+		},
+		{
+			name: "subscription outside of the allowed ones is rejected",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a"},
+			body: `{"Data":{"req":{"Query":{"subscription":"sub-a,sub-b","format":"records"}}}}`,
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusForbidden, summary.Status)
+				assert.Empty(t, summary.Subscriptions)
+				assert.Empty(t, summary.Records)
+				assert.Contains(t, summary.Errors[0], "subscription sub-b is not allowed")
+			},
+		},
+		{
+			name: "inventory is written to the blob output binding",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a", "SCRAPPER_BLOB_OUTPUT": "inventory"},
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusOK, summary.Status)
+				require.IsType(t, "", resp.Outputs["inventory"])
+				lines := strings.Split(strings.TrimSpace(resp.Outputs["inventory"].(string)), "\n")
+				require.Len(t, lines, 1)
+				var record map[string]any
+				require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+				assert.Equal(t, "managedCluster", record["kind"])
+				assert.Equal(t, "sub-a", record["subscriptionId"])
+			},
+		},
+		{
+			name: "blob output binding is not filled unless configured",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a"},
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.NotContains(t, resp.Outputs, "inventory")
+			},
+		},
+		{
+			name: "malformed invoke request",
+			body: `{"Data":`,
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusBadRequest, summary.Status)
+				assert.Contains(t, summary.Errors[0], "invalid request")
+			},
+		},
+		{
+			name: "invalid configuration",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a", "SCRAPPER_TIMEOUT": "soon"},
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusInternalServerError, summary.Status)
+				assert.Empty(t, summary.Subscriptions)
+				assert.Contains(t, summary.Errors[0], "invalid SCRAPPER_TIMEOUT")
+			},
+		},
+		{
+			name: "unknown cloud",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a", "AZURE_CLOUD": "mars"},
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusInternalServerError, summary.Status)
+				assert.Contains(t, summary.Errors[0], `invalid AZURE_CLOUD: unknown cloud "mars"`)
+			},
+		},
+		{
+			name: "resource kinds from the trigger request",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a", "SCRAPPER_EXCLUDE_KINDS": "managedCluster"},
+			body: `{"Data":{"req":{"Query":{"include":"provider","format":"records"}}}}`,
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusOK, summary.Status)
+				assert.Empty(t, summary.Records)
+				require.Len(t, summary.Subscriptions, 1)
+				assert.Len(t, summary.Subscriptions[0].Kinds, 1)
+			},
+		},
+		{
+			name: "unknown resource kind in env",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a", "SCRAPPER_INCLUDE_KINDS": "cluster"},
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusInternalServerError, summary.Status)
+				assert.Contains(t, summary.Errors[0], `invalid SCRAPPER_INCLUDE_KINDS: unknown resource kind "cluster"`)
+			},
+		},
+		{
+			name: "invalid resource group pattern",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a", "SCRAPPER_RESOURCE_GROUPS": "rg-[a"},
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusInternalServerError, summary.Status)
+				assert.Contains(t, summary.Errors[0], `invalid resource group scope: invalid resource group pattern "rg-[a"`)
+			},
+		},
+		{
+			name: "unknown kind timeout",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a", "SCRAPPER_KIND_TIMEOUTS": "cluster=10s"},
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusInternalServerError, summary.Status)
+				assert.Contains(t, summary.Errors[0], `invalid configuration: invalid SCRAPPER_KIND_TIMEOUTS entry "cluster=10s": unknown resource kind "cluster"`)
+			},
+		},
+		{
+			name: "unknown backend",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a", "SCRAPPER_BACKEND": "graph"},
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusInternalServerError, summary.Status)
+				assert.Contains(t, summary.Errors[0], `invalid SCRAPPER_BACKEND: unknown backend "graph"`)
+			},
+		},
+		{
+			name: "missing cassette",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a", "SCRAPPER_REPLAY": "/does/not/exist.json"},
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusInternalServerError, summary.Status)
+				assert.Contains(t, summary.Errors[0], "invalid SCRAPPER_REPLAY: failed to read cassette")
+			},
+		},
+		{
+			name: "invalid credential chain",
+			env:  map[string]string{"AZURE_SUBSCRIPTION": "sub-a", "AZURE_CREDENTIAL_CHAIN": "managedIdentity,password"},
+			want: func(t *testing.T, resp InvokeResponse, summary Summary) {
+				assert.Equal(t, http.StatusInternalServerError, summary.Status)
+				assert.Contains(t, summary.Errors[0], `failed to obtain a credential: credential 1 (password): unknown credential type "password"`)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			w := httptest.NewRecorder()
+			handler := NewHandler(append(oneClusterPerSubscription(), WithSink(NewMemorySink()))...)
+			handler(w, httptest.NewRequest(http.MethodPost, "/scrapper", strings.NewReader(tt.body)))
+
+			resp, summary := decodeInvokeResponse(t, w)
+			tt.want(t, resp, summary)
+		})
+	}
+}
+
+func decodeInvokeResponse(t *testing.T, w *httptest.ResponseRecorder) (InvokeResponse, Summary) {
+	var resp InvokeResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+
+	var summary Summary
+	require.IsType(t, map[string]any{}, resp.Outputs["res"])
+	body := resp.Outputs["res"].(map[string]any)["body"]
+	require.IsType(t, "", body)
+	require.NoError(t, json.Unmarshal([]byte(body.(string)), &summary))
+	return resp, summary
+}
+
+func TestHandleTimer(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want func(t *testing.T, code int, resp InvokeResponse)
+	}{
+		{
+			name: "logs the schedule status and scrapes",
+			body: `{"Data":{"timer":{"Schedule":{"AdjustForDST":true},"ScheduleStatus":{"Last":"2023-10-01T00:00:00Z","Next":"2023-10-01T06:00:00Z","LastUpdated":"2023-10-01T00:00:00Z"},"IsPastDue":true}},"Metadata":{}}`,
+			want: func(t *testing.T, code int, resp InvokeResponse) {
+				assert.Equal(t, http.StatusOK, code)
+				assert.Empty(t, resp.Outputs)
+				require.NotEmpty(t, resp.Logs)
+				assert.Equal(t, "timer triggered, past due: true, last: 2023-10-01T00:00:00Z, next: 2023-10-01T06:00:00Z", resp.Logs[0])
+				assert.Equal(t, float64(http.StatusOK), resp.ReturnValue.(map[string]any)["status"])
+			},
+		},
+		{
+			name: "first run has no schedule status",
+			body: `{"Data":{"timer":{"Schedule":{"AdjustForDST":true},"IsPastDue":false}}}`,
+			want: func(t *testing.T, code int, resp InvokeResponse) {
+				assert.Equal(t, http.StatusOK, code)
+				assert.Equal(t, "timer triggered, past due: false, no schedule status", resp.Logs[0])
+			},
+		},
+		{
+			name: "malformed timer binding",
+			body: `{"Data":{"timer":[]}}`,
+			want: func(t *testing.T, code int, resp InvokeResponse) {
+				assert.Equal(t, http.StatusBadRequest, code)
+				assert.Contains(t, resp.Logs[0], "malformed timer binding")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AZURE_SUBSCRIPTION", "sub-a")
+			w := httptest.NewRecorder()
+			handler := NewTimerHandler(append(oneClusterPerSubscription(), WithSink(NewMemorySink()))...)
+			handler(w, httptest.NewRequest(http.MethodPost, "/scheduled", strings.NewReader(tt.body)))
+
+			var resp InvokeResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+			tt.want(t, w.Code, resp)
+		})
+	}
+}
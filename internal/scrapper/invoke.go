@@ -0,0 +1,189 @@
+package scrapper
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// InvokeRequest is the payload the azure functions host posts to a custom handler.
+// Data holds the input bindings keyed by binding name, Metadata the trigger metadata.
+type InvokeRequest struct {
+	Data     map[string]json.RawMessage
+	Metadata map[string]json.RawMessage
+}
+
+// HTTPTriggerRequest is the original request received by an http trigger binding.
+type HTTPTriggerRequest struct {
+	URL     string `json:"Url"`
+	Method  string
+	Query   map[string]string
+	Headers map[string][]string
+	Params  map[string]string
+	Body    json.RawMessage
+}
+
+// TimerTriggerInfo is the input of a timer trigger binding.
+type TimerTriggerInfo struct {
+	Schedule       TimerSchedule
+	ScheduleStatus *TimerScheduleStatus
+	IsPastDue      bool
+}
+
+// TimerSchedule describes how the timer schedule is evaluated.
+type TimerSchedule struct {
+	AdjustForDST bool
+}
+
+// TimerScheduleStatus holds the last and next occurrences of the schedule, it is absent on the first run.
+type TimerScheduleStatus struct {
+	Last        time.Time
+	Next        time.Time
+	LastUpdated time.Time
+}
+
+const (
+	// FormatSummary only returns the scrape summary to the caller.
+	FormatSummary = "summary"
+	// FormatRecords returns the scraped records alongside the summary.
+	FormatRecords = "records"
+)
+
+// ScrapeParameters are the per invocation settings a trigger can pass to the scrapper.
+type ScrapeParameters struct {
+	Subscriptions []string `json:"subscriptions"`
+	Format        string   `json:"format"`
+	// Include and Exclude select the resource kinds to scrape, see WithKinds and WithoutKinds.
+	Include []Kind `json:"include"`
+	Exclude []Kind `json:"exclude"`
+}
+
+// DecodeInvokeRequest reads the invoke payload from the request body, an empty body decodes to an empty request.
+func DecodeInvokeRequest(r *http.Request) (*InvokeRequest, error) {
+	req := &InvokeRequest{}
+	if r.Body == nil {
+		return req, nil
+	}
+
+	content, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read invoke request: %w", err)
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return req, nil
+	}
+	if err = json.Unmarshal(content, req); err != nil {
+		return nil, fmt.Errorf("malformed invoke request: %w", err)
+	}
+	return req, nil
+}
+
+// HTTPRequest decodes the http trigger input binding with the given name, it returns nil when the binding is absent.
+func (i *InvokeRequest) HTTPRequest(binding string) (*HTTPTriggerRequest, error) {
+	raw, ok := i.Data[binding]
+	if !ok {
+		return nil, nil
+	}
+
+	var req HTTPTriggerRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return nil, fmt.Errorf("malformed %s binding: %w", binding, err)
+	}
+	return &req, nil
+}
+
+// TimerInfo decodes the timer trigger input binding with the given name, it returns nil when the binding is absent.
+func (i *InvokeRequest) TimerInfo(binding string) (*TimerTriggerInfo, error) {
+	raw, ok := i.Data[binding]
+	if !ok {
+		return nil, nil
+	}
+
+	var info TimerTriggerInfo
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return nil, fmt.Errorf("malformed %s binding: %w", binding, err)
+	}
+	return &info, nil
+}
+
+// String describes the timer occurrence for the invocation logs.
+func (t *TimerTriggerInfo) String() string {
+	if t.ScheduleStatus == nil {
+		return fmt.Sprintf("timer triggered, past due: %v, no schedule status", t.IsPastDue)
+	}
+	return fmt.Sprintf("timer triggered, past due: %v, last: %s, next: %s", t.IsPastDue,
+		t.ScheduleStatus.Last.Format(time.RFC3339), t.ScheduleStatus.Next.Format(time.RFC3339))
+}
+
+// ScrapeParameters reads the parameters from the json body, query parameters take precedence over the body.
+// The subscription query parameter is a comma separated list of subscription ids,
+// the include and exclude query parameters comma separated lists of resource kinds.
+func (h *HTTPTriggerRequest) ScrapeParameters() (ScrapeParameters, error) {
+	var params ScrapeParameters
+	body, err := h.body()
+	if err != nil {
+		return params, err
+	}
+	if len(bytes.TrimSpace(body)) > 0 {
+		if err = json.Unmarshal(body, &params); err != nil {
+			return params, fmt.Errorf("malformed request body: %w", err)
+		}
+	}
+
+	if val := h.Query["subscription"]; val != "" {
+		params.Subscriptions = splitList(val)
+	}
+	if val := h.Query["format"]; val != "" {
+		params.Format = val
+	}
+	if val := h.Query["include"]; val != "" {
+		params.Include = toKinds(splitList(val))
+	}
+	if val := h.Query["exclude"]; val != "" {
+		params.Exclude = toKinds(splitList(val))
+	}
+	if err = validateKinds(append(append([]Kind{}, params.Include...), params.Exclude...)...); err != nil {
+		return params, err
+	}
+
+	switch params.Format {
+	case "":
+		params.Format = FormatSummary
+	case FormatSummary, FormatRecords:
+	default:
+		return params, fmt.Errorf("unknown format %q", params.Format)
+	}
+	return params, nil
+}
+
+// body returns the raw request body, the functions host forwards it either as a json string or as a json value.
+func (h *HTTPTriggerRequest) body() ([]byte, error) {
+	if len(h.Body) == 0 || string(h.Body) == "null" {
+		return nil, nil
+	}
+	if h.Body[0] != '"' {
+		return h.Body, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(h.Body, &s); err != nil {
+		return nil, errors.New("malformed request body")
+	}
+	return []byte(s), nil
+}
+
+// splitList splits a comma separated list, dropping empty entries.
+func splitList(val string) []string {
+	var items []string
+	for _, item := range strings.Split(val, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,119 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeInvokeRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want func(t *testing.T, params ScrapeParameters, err error)
+	}{
+		{
+			name: "empty body uses defaults",
+			body: "",
+			want: func(t *testing.T, params ScrapeParameters, err error) {
+				require.NoError(t, err)
+				assert.Empty(t, params.Subscriptions)
+				assert.Equal(t, FormatSummary, params.Format)
+			},
+		},
+		{
+			name: "query parameters",
+			body: `{"Data":{"req":{"Url":"http://localhost/api/scrapper","Method":"GET","Query":{"subscription":"sub-a, sub-b","format":"records"}}},"Metadata":{}}`,
+			want: func(t *testing.T, params ScrapeParameters, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"sub-a", "sub-b"}, params.Subscriptions)
+				assert.Equal(t, FormatRecords, params.Format)
+			},
+		},
+		{
+			name: "string body",
+			body: `{"Data":{"req":{"Method":"POST","Body":"{\"subscriptions\":[\"sub-a\"]}"}}}`,
+			want: func(t *testing.T, params ScrapeParameters, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"sub-a"}, params.Subscriptions)
+			},
+		},
+		{
+			name: "json body is overridden by query",
+			body: `{"Data":{"req":{"Method":"POST","Query":{"subscription":"sub-b"},"Body":{"subscriptions":["sub-a"],"format":"records"}}}}`,
+			want: func(t *testing.T, params ScrapeParameters, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []string{"sub-b"}, params.Subscriptions)
+				assert.Equal(t, FormatRecords, params.Format)
+			},
+		},
+		{
+			name: "malformed payload",
+			body: `{"Data":`,
+			want: func(t *testing.T, params ScrapeParameters, err error) {
+				assert.ErrorContains(t, err, "malformed invoke request")
+			},
+		},
+		{
+			name: "malformed binding",
+			body: `{"Data":{"req":"not-a-request"}}`,
+			want: func(t *testing.T, params ScrapeParameters, err error) {
+				assert.ErrorContains(t, err, "malformed req binding")
+			},
+		},
+		{
+			name: "malformed body",
+			body: `{"Data":{"req":{"Body":"{not json"}}}`,
+			want: func(t *testing.T, params ScrapeParameters, err error) {
+				assert.ErrorContains(t, err, "malformed request body")
+			},
+		},
+		{
+			name: "resource kinds",
+			body: `{"Data":{"req":{"Query":{"include":"nodePool,managedCluster"},"Body":{"include":["provider"],"exclude":["managedCluster"]}}}}`,
+			want: func(t *testing.T, params ScrapeParameters, err error) {
+				require.NoError(t, err)
+				assert.Equal(t, []Kind{KindNodePool, KindCluster}, params.Include)
+				assert.Equal(t, []Kind{KindCluster}, params.Exclude)
+			},
+		},
+		{
+			name: "unknown resource kind",
+			body: `{"Data":{"req":{"Query":{"exclude":"cluster"}}}}`,
+			want: func(t *testing.T, params ScrapeParameters, err error) {
+				assert.ErrorContains(t, err, `unknown resource kind "cluster", expected one of`)
+			},
+		},
+		{
+			name: "unknown format",
+			body: `{"Data":{"req":{"Query":{"format":"xml"}}}}`,
+			want: func(t *testing.T, params ScrapeParameters, err error) {
+				assert.EqualError(t, err, `unknown format "xml"`)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := DecodeInvokeRequest(httptest.NewRequest(http.MethodPost, "/scrapper", strings.NewReader(tt.body)))
+			if err != nil {
+				tt.want(t, ScrapeParameters{}, err)
+				return
+			}
+			trigger, err := req.HTTPRequest("req")
+			if err != nil {
+				tt.want(t, ScrapeParameters{}, err)
+				return
+			}
+			if trigger == nil {
+				trigger = &HTTPTriggerRequest{}
+			}
+			params, err := trigger.ScrapeParameters()
+			tt.want(t, params, err)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package scrapper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	subscription "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"golang.org/x/sync/errgroup"
+)
+
+// MultiScrapper runs a Scrapper for each of several subscriptions, sharing a single sink.
+type MultiScrapper struct {
+	credential         az.TokenCredential
+	subscriptions      []string
+	subscriptionClient SubscriptionPager
+	concurrency        int
+	timeout            time.Duration
+	sink               Sink
+	opts               []OptionsFunc
+	graphClient        ResourceGraphClient
+	graphKinds         []Kind
+}
+
+// NewMultiScrapper initialize a scrapper for the provided subscriptions.
+// When no subscriptions are provided they are discovered through the subscriptions api when the scrapper runs.
+// The option functions are applied to every per subscription scrapper.
+func NewMultiScrapper(cred az.TokenCredential, subs []string, opts ...OptionsFunc) (*MultiScrapper, error) {
+	o := resolveOptions(opts...)
+	o.useThrottle()
+	_, collected, err := o.selectKinds()
+	if err != nil {
+		return nil, err
+	}
+
+	sc, err := o.subscriptionClientFactory(cred, o.baseClientOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	m := &MultiScrapper{
+		credential:         cred,
+		subscriptions:      subs,
+		subscriptionClient: sc,
+		concurrency:        o.subscriptionConcurrency,
+		timeout:            o.timeout,
+		sink:               o.sink,
+		opts:               opts,
+		graphKinds:         o.graphKinds(collected),
+	}
+	if len(m.graphKinds) > 0 {
+		if m.graphClient, err = o.graphClientFactory(cred, o.baseClientOptions()); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Run scrapes every subscription, at most subscriptionConcurrency at a time, and returns a report per subscription.
+// A failing subscription does not stop the others, failures are returned together as SubscriptionErrors.
+// With resource graph, the subscriptions are queried and scraped a batch at a time so only the rows of a batch are held.
+func (m *MultiScrapper) Run(ctx context.Context) (reports []*RunReport, err error) {
+	subs, err := m.resolveSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = m.sink.Open(ctx); err != nil {
+		return nil, fmt.Errorf("failed to open sink: %w", err)
+	}
+	defer func() {
+		err = errors.Join(err, m.sink.Flush(), m.sink.Close())
+	}()
+
+	var mu sync.Mutex
+	failures := SubscriptionErrors{}
+
+	for _, batch := range m.batches(subs) {
+		graph := m.queryGraph(ctx, batch)
+		// the batch is scraped before the next one is queried, so the rows of a single batch are held
+		var g errgroup.Group
+		g.SetLimit(m.concurrency)
+		for _, sub := range batch {
+			sub := sub
+			results := graph[strings.ToLower(sub)]
+			g.Go(func() error {
+				report, err := m.runSubscription(ctx, sub, results)
+				mu.Lock()
+				defer mu.Unlock()
+				if report != nil {
+					reports = append(reports, report)
+				}
+				if err != nil {
+					failures[sub] = err
+				}
+				return nil
+			})
+		}
+		_ = g.Wait()
+	}
+
+	sort.Slice(reports, func(i, j int) bool { return reports[i].SubscriptionID < reports[j].SubscriptionID })
+	if len(failures) > 0 {
+		return reports, failures
+	}
+	return reports, nil
+}
+
+func (m *MultiScrapper) runSubscription(ctx context.Context, sub string, graph graphResults) (*RunReport, error) {
+	opts := append(append([]OptionsFunc{}, m.opts...), WithSink(sharedSink{m.sink}))
+	if graph != nil {
+		opts = append(opts, func(opt *Options) { opt.graphResults = graph })
+	}
+	s, err := NewScrapper(m.credential, sub, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize scrapper: %w", err)
+	}
+	return s.Run(ctx)
+}
+
+// batches splits the subscriptions in the batches a resource graph query covers, there is a single batch when every kind
+// goes through ARM.
+func (m *MultiScrapper) batches(subs []string) [][]string {
+	if m.graphClient == nil {
+		return [][]string{subs}
+	}
+	var batches [][]string
+	for start := 0; start < len(subs); start += graphBatchSize {
+		end := start + graphBatchSize
+		if end > len(subs) {
+			end = len(subs)
+		}
+		batches = append(batches, subs[start:end])
+	}
+	return batches
+}
+
+// queryGraph lists the kinds listed from resource graph for every subscription of a batch at once,
+// it returns nil when every kind goes through ARM.
+func (m *MultiScrapper) queryGraph(ctx context.Context, subs []string) map[string]graphResults {
+	if m.graphClient == nil {
+		return nil
+	}
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+	return queryGraph(ctx, m.graphClient, m.graphKinds, subs)
+}
+
+func (m *MultiScrapper) resolveSubscriptions(ctx context.Context) ([]string, error) {
+	if len(m.subscriptions) > 0 {
+		return m.subscriptions, nil
+	}
+
+	if m.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, m.timeout)
+		defer cancel()
+	}
+
+	var subs []string
+	err := m.ListSubscriptions(ctx, func(s *subscription.Subscription) error {
+		if s.SubscriptionID != nil && s.State != nil && *s.State == subscription.SubscriptionStateEnabled {
+			subs = append(subs, *s.SubscriptionID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover subscriptions: %w", err)
+	}
+	return subs, nil
+}
+
+// SubscriptionErrors holds the error of every subscription that failed to scrape, keyed by subscription id.
+type SubscriptionErrors map[string]error
+
+func (e SubscriptionErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, sub := range e.subscriptions() {
+		msgs = append(msgs, fmt.Sprintf("subscription %s: %v", sub, e[sub]))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e SubscriptionErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, sub := range e.subscriptions() {
+		errs = append(errs, e[sub])
+	}
+	return errs
+}
+
+func (e SubscriptionErrors) subscriptions() []string {
+	subs := make([]string, 0, len(e))
+	for sub := range e {
+		subs = append(subs, sub)
+	}
+	sort.Strings(subs)
+	return subs
+}
+
+// sharedSink lets several scrappers write to a sink whose lifecycle is owned by the MultiScrapper.
+type sharedSink struct {
+	Sink
+}
+
+func (sharedSink) Open(_ context.Context) error {
+	return nil
+}
+
+func (sharedSink) Close() error {
+	return nil
+}
@@ -0,0 +1,156 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	subscription "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultiScrapper_Run(t *testing.T) {
+	tests := []struct {
+		name                string
+		subscriptions       []string
+		subscriptionFactory SubscriptionClientFactory
+		want                func(t *testing.T, records []Record, err error)
+	}{
+		{
+			name:          "scrapes every provided subscription",
+			subscriptions: []string{"sub-a", "sub-b"},
+			want: func(t *testing.T, records []Record, err error) {
+				assert.NoError(t, err)
+				assert.ElementsMatch(t, []string{"sub-a", "sub-b"}, recordSubscriptions(records))
+			},
+		},
+		{
+			name:          "failing subscription does not stop the others",
+			subscriptions: []string{"sub-a", "bad", "sub-b"},
+			want: func(t *testing.T, records []Record, err error) {
+				var failures SubscriptionErrors
+				require.True(t, errors.As(err, &failures))
+				assert.Len(t, failures, 1)
+				assert.Contains(t, failures, "bad")
+				assert.Subset(t, recordSubscriptions(records), []string{"sub-a", "sub-b"})
+			},
+		},
+		{
+			name: "discovers enabled subscriptions",
+			subscriptionFactory: scrappertest.SubscriptionsFactory(scrappertest.NewSubscriptionPager(scrappertest.Items(
+				&subscription.Subscription{SubscriptionID: to("sub-a"), State: to(subscription.SubscriptionStateEnabled)},
+				&subscription.Subscription{SubscriptionID: to("sub-b"), State: to(subscription.SubscriptionStateDisabled)},
+			))),
+			want: func(t *testing.T, records []Record, err error) {
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"sub-a"}, recordSubscriptions(records))
+			},
+		},
+		{
+			name: "subscription discovery fails",
+			subscriptionFactory: scrappertest.SubscriptionsFactory(scrappertest.NewSubscriptionPager(
+				scrappertest.Fail[subscription.Subscription](errors.New("failed to iterate")))),
+			want: func(t *testing.T, records []Record, err error) {
+				assert.ErrorContains(t, err, "failed to discover subscriptions")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := NewMemorySink()
+			options := append(oneClusterPerSubscription(), WithSink(sink), WithSubscriptionConcurrency(2))
+			if tt.subscriptionFactory != nil {
+				options = append(options, WithSubscriptionsFactory(tt.subscriptionFactory))
+			}
+
+			s, err := NewMultiScrapper(testCred(), tt.subscriptions, options...)
+			require.NoError(t, err)
+			_, err = s.Run(context.Background())
+			tt.want(t, sink.Records(), err)
+		})
+	}
+}
+
+func TestMultiScrapper_SubscriptionConcurrency(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		sink := NewMemorySink()
+		s, err := NewMultiScrapper(testCred(), []string{"sub-a", "sub-b"}, append(oneClusterPerSubscription(), WithSink(sink), WithSubscriptionConcurrency(n))...)
+		require.NoError(t, err)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, err := s.Run(context.Background())
+			assert.NoError(t, err)
+		}()
+		select {
+		case <-done:
+			assert.ElementsMatch(t, []string{"sub-a", "sub-b"}, recordSubscriptions(sink.Records()))
+		case <-time.After(5 * time.Second):
+			t.Fatalf("scrape with a concurrency of %d never completed", n)
+		}
+	}
+}
+
+func TestNewMultiScrapper(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []OptionsFunc
+		wantErr string
+	}{
+		{
+			name: "subscription client fails",
+			options: []OptionsFunc{WithSubscriptionsFactory(func(credential az.TokenCredential, options *arm.ClientOptions) (SubscriptionPager, error) {
+				return nil, errors.New("failed to create client")
+			})},
+			wantErr: "failed to create client",
+		},
+		{
+			name:    "invalid resource group scope",
+			options: []OptionsFunc{WithResourceGroupScope(ResourceGroupScope{Patterns: []string{"rg-[a"}})},
+			wantErr: `invalid resource group pattern "rg-[a": syntax error in pattern`,
+		},
+		{
+			name:    "subscription level kind in a resource group scope",
+			options: []OptionsFunc{WithKinds(KindProvider), WithResourceGroupScope(ResourceGroupScope{Names: []string{"rg-a"}})},
+			wantErr: "resource kind provider is subscription level and cannot be scraped in a resource group scope",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewMultiScrapper(testCred(), []string{"sub-1", "sub-2"}, tt.options...)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+// oneClusterPerSubscription stubs every client so a run emits a single cluster, the resource group client fails for the "bad" subscription.
+func oneClusterPerSubscription() []OptionsFunc {
+	return append(scrappertest.Empty(),
+		WithResourceGroupsFactory(func(sub string, _ az.TokenCredential, _ *arm.ClientOptions) (ResourceGroupsPager, error) {
+			if sub == "bad" {
+				return scrappertest.NewResourceGroupsPager(scrappertest.Fail[resource.ResourceGroup](errors.New("failed to iterate"))), nil
+			}
+			return scrappertest.NewResourceGroupsPager(), nil
+		}),
+		WithClusterFactory(func(_ string, _ az.TokenCredential, _ *arm.ClientOptions) (ClusterPager, error) {
+			return scrappertest.NewClusterPager(scrappertest.Items(&container.ManagedCluster{})), nil
+		}),
+	)
+}
+
+func recordSubscriptions(records []Record) []string {
+	var subs []string
+	for _, r := range records {
+		subs = append(subs, r.SubscriptionID)
+	}
+	return subs
+}
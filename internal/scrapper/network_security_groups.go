@@ -0,0 +1,180 @@
+package scrapper
+
+import (
+	"context"
+	"encoding/json"
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	network "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v2"
+)
+
+const (
+	KindNetworkSecurityGroup Kind = "networkSecurityGroup"
+	KindSecurityRule         Kind = "securityRule"
+)
+
+// NetworkSecurityGroupPager used to scrape network security group information
+type NetworkSecurityGroupPager interface {
+	NewListAllPager(options *network.SecurityGroupsClientListAllOptions) *rt.Pager[network.SecurityGroupsClientListAllResponse]
+	NewListPager(resourceGroupName string, options *network.SecurityGroupsClientListOptions) *rt.Pager[network.SecurityGroupsClientListResponse]
+}
+
+type NetworkSecurityGroupClientFactory = ClientFactory[NetworkSecurityGroupPager]
+
+func init() {
+	Register(Collector[NetworkSecurityGroupPager]{
+		Kind: KindNetworkSecurityGroup,
+		Factory: func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (NetworkSecurityGroupPager, error) {
+			return network.NewSecurityGroupsClient(subscriptionID, credential, options)
+		},
+		Collect: func(ctx context.Context, c *Collection, _ NetworkSecurityGroupPager) error {
+			return c.Scrapper().ListNetworkSecurityGroups(ctx, emitHandler[network.SecurityGroup](c, KindNetworkSecurityGroup))
+		},
+		ListByResourceGroup: func(ctx context.Context, c *Collection, _ NetworkSecurityGroupPager, resourceGroup string) error {
+			return c.Scrapper().ListNetworkSecurityGroupsByResourceGroup(ctx, resourceGroup, emitHandler[network.SecurityGroup](c, KindNetworkSecurityGroup))
+		},
+		Graph: &GraphQuery{
+			Query: graphResources("microsoft.network/networksecuritygroups"),
+			Collect: func(ctx context.Context, c *Collection, rows []json.RawMessage) error {
+				return graphRows(ctx, rows, emitHandler[network.SecurityGroup](c, KindNetworkSecurityGroup))
+			},
+		},
+	})
+	Register(Collector[any]{
+		Kind:      KindSecurityRule,
+		DependsOn: []Kind{KindNetworkSecurityGroup},
+		Collect: func(ctx context.Context, c *Collection, _ any) error {
+			groups := Collected[network.SecurityGroup](c, KindNetworkSecurityGroup)
+			return ListSecurityRules(ctx, groups, emitHandler[SecurityRule](c, KindSecurityRule))
+		},
+	})
+}
+
+func WithNetworkSecurityGroupFactory(f NetworkSecurityGroupClientFactory) OptionsFunc {
+	return WithFactory(KindNetworkSecurityGroup, f)
+}
+
+func (s *Scrapper) ListNetworkSecurityGroups(ctx context.Context, pageHandler pageHandler[network.SecurityGroup]) error {
+	pager := clientFor[NetworkSecurityGroupPager](s, KindNetworkSecurityGroup).NewListAllPager(nil)
+	return listPages(ctx, pager, func(p network.SecurityGroupsClientListAllResponse) []*network.SecurityGroup { return p.Value }, pageHandler)
+}
+
+func (s *Scrapper) ListNetworkSecurityGroupsByResourceGroup(ctx context.Context, resourceGroup string, pageHandler pageHandler[network.SecurityGroup]) error {
+	pager := clientFor[NetworkSecurityGroupPager](s, KindNetworkSecurityGroup).NewListPager(resourceGroup, nil)
+	return listPages(ctx, pager, func(p network.SecurityGroupsClientListResponse) []*network.SecurityGroup { return p.Value }, pageHandler)
+}
+
+// SecurityRule is a single rule of a network security group, flattened with the subnets and network interfaces
+// the group is associated with so exposure can be queried without walking the group.
+// Prefixes, ports and application security groups merge the singular and plural fields of the rule.
+type SecurityRule struct {
+	NetworkSecurityGroupID string `json:"networkSecurityGroupId"`
+	ID                     string `json:"id,omitempty"`
+	Name                   string `json:"name,omitempty"`
+	// Default is set for the default rules Azure adds to every group.
+	Default                                bool     `json:"default"`
+	Direction                              string   `json:"direction,omitempty"`
+	Priority                               int32    `json:"priority,omitempty"`
+	Access                                 string   `json:"access,omitempty"`
+	Protocol                               string   `json:"protocol,omitempty"`
+	SourceAddressPrefixes                  []string `json:"sourceAddressPrefixes,omitempty"`
+	SourceApplicationSecurityGroupIDs      []string `json:"sourceApplicationSecurityGroupIds,omitempty"`
+	SourcePortRanges                       []string `json:"sourcePortRanges,omitempty"`
+	DestinationAddressPrefixes             []string `json:"destinationAddressPrefixes,omitempty"`
+	DestinationApplicationSecurityGroupIDs []string `json:"destinationApplicationSecurityGroupIds,omitempty"`
+	DestinationPortRanges                  []string `json:"destinationPortRanges,omitempty"`
+	SubnetIDs                              []string `json:"subnetIds,omitempty"`
+	NetworkInterfaceIDs                    []string `json:"networkInterfaceIds,omitempty"`
+}
+
+// ListSecurityRules hands the rules of every network security group to the page handler, custom rules first.
+func ListSecurityRules(ctx context.Context, groups []*network.SecurityGroup, pageHandler pageHandler[SecurityRule]) error {
+	var rules []*SecurityRule
+	for _, group := range groups {
+		if group.ID == nil || group.Properties == nil {
+			continue
+		}
+		var subnets, nics []string
+		for _, subnet := range group.Properties.Subnets {
+			if subnet != nil {
+				subnets = appendNonEmpty(subnets, subnet.ID)
+			}
+		}
+		for _, nic := range group.Properties.NetworkInterfaces {
+			if nic != nil {
+				nics = appendNonEmpty(nics, nic.ID)
+			}
+		}
+		for _, rule := range group.Properties.SecurityRules {
+			if rule != nil {
+				rules = append(rules, flattenRule(*group.ID, rule, false, subnets, nics))
+			}
+		}
+		for _, rule := range group.Properties.DefaultSecurityRules {
+			if rule != nil {
+				rules = append(rules, flattenRule(*group.ID, rule, true, subnets, nics))
+			}
+		}
+	}
+	return processPage(ctx, rules, pageHandler)
+}
+
+func flattenRule(groupID string, rule *network.SecurityRule, isDefault bool, subnets []string, nics []string) *SecurityRule {
+	r := &SecurityRule{
+		NetworkSecurityGroupID: groupID,
+		ID:                     deref(rule.ID),
+		Name:                   deref(rule.Name),
+		Default:                isDefault,
+		SubnetIDs:              subnets,
+		NetworkInterfaceIDs:    nics,
+	}
+	p := rule.Properties
+	if p == nil {
+		return r
+	}
+	r.Direction = string(deref(p.Direction))
+	r.Access = string(deref(p.Access))
+	r.Protocol = string(deref(p.Protocol))
+	r.Priority = deref(p.Priority)
+	r.SourceAddressPrefixes = merge(p.SourceAddressPrefix, p.SourceAddressPrefixes)
+	r.SourcePortRanges = merge(p.SourcePortRange, p.SourcePortRanges)
+	r.DestinationAddressPrefixes = merge(p.DestinationAddressPrefix, p.DestinationAddressPrefixes)
+	r.DestinationPortRanges = merge(p.DestinationPortRange, p.DestinationPortRanges)
+	r.SourceApplicationSecurityGroupIDs = applicationSecurityGroupIDs(p.SourceApplicationSecurityGroups)
+	r.DestinationApplicationSecurityGroupIDs = applicationSecurityGroupIDs(p.DestinationApplicationSecurityGroups)
+	return r
+}
+
+func applicationSecurityGroupIDs(groups []*network.ApplicationSecurityGroup) []string {
+	var ids []string
+	for _, g := range groups {
+		if g != nil {
+			ids = appendNonEmpty(ids, g.ID)
+		}
+	}
+	return ids
+}
+
+// merge returns the value of a singular field followed by the values of its plural counterpart, skipping empty ones.
+func merge(single *string, many []*string) []string {
+	values := appendNonEmpty(nil, single)
+	for _, v := range many {
+		values = appendNonEmpty(values, v)
+	}
+	return values
+}
+
+func appendNonEmpty(values []string, v *string) []string {
+	if v == nil || *v == "" {
+		return values
+	}
+	return append(values, *v)
+}
+
+func deref[T any](v *T) T {
+	if v == nil {
+		return *new(T)
+	}
+	return *v
+}
@@ -0,0 +1,165 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
+	"context"
+	"errors"
+	"testing"
+
+	network "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListSecurityRules(t *testing.T) {
+	nsgID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkSecurityGroups/nsg"
+	subnetID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet/subnets/default"
+	nicID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/networkInterfaces/nic"
+	tests := []struct {
+		name   string
+		groups []*network.SecurityGroup
+		expect func(t *testing.T, rules []*SecurityRule, err error)
+	}{
+		{
+			name: "rules are flattened with the subnets and nics of their group",
+			groups: []*network.SecurityGroup{{
+				ID: to(nsgID),
+				Properties: &network.SecurityGroupPropertiesFormat{
+					Subnets:           []*network.Subnet{{ID: to(subnetID)}},
+					NetworkInterfaces: []*network.Interface{{ID: to(nicID)}},
+					SecurityRules: []*network.SecurityRule{{
+						ID:   to(nsgID + "/securityRules/allow-ssh"),
+						Name: to("allow-ssh"),
+						Properties: &network.SecurityRulePropertiesFormat{
+							Direction:                            to(network.SecurityRuleDirectionInbound),
+							Access:                               to(network.SecurityRuleAccessAllow),
+							Protocol:                             to(network.SecurityRuleProtocolTCP),
+							Priority:                             to[int32](100),
+							SourceAddressPrefix:                  to("*"),
+							SourcePortRange:                      to("*"),
+							DestinationAddressPrefixes:           []*string{to("10.0.0.4"), to("10.0.0.5")},
+							DestinationPortRanges:                []*string{to("22"), to("2222-2230")},
+							DestinationApplicationSecurityGroups: []*network.ApplicationSecurityGroup{{ID: to("asg-bastion")}},
+						},
+					}},
+					DefaultSecurityRules: []*network.SecurityRule{{
+						Name: to("DenyAllInBound"),
+						Properties: &network.SecurityRulePropertiesFormat{
+							Direction: to(network.SecurityRuleDirectionInbound),
+							Access:    to(network.SecurityRuleAccessDeny),
+							Priority:  to[int32](65500),
+						},
+					}},
+				},
+			}},
+			expect: func(t *testing.T, rules []*SecurityRule, err error) {
+				require.NoError(t, err)
+				require.Len(t, rules, 2)
+				assert.Equal(t, &SecurityRule{
+					NetworkSecurityGroupID:                 nsgID,
+					ID:                                     nsgID + "/securityRules/allow-ssh",
+					Name:                                   "allow-ssh",
+					Direction:                              "Inbound",
+					Priority:                               100,
+					Access:                                 "Allow",
+					Protocol:                               "Tcp",
+					SourceAddressPrefixes:                  []string{"*"},
+					SourcePortRanges:                       []string{"*"},
+					DestinationAddressPrefixes:             []string{"10.0.0.4", "10.0.0.5"},
+					DestinationApplicationSecurityGroupIDs: []string{"asg-bastion"},
+					DestinationPortRanges:                  []string{"22", "2222-2230"},
+					SubnetIDs:                              []string{subnetID},
+					NetworkInterfaceIDs:                    []string{nicID},
+				}, rules[0])
+				assert.True(t, rules[1].Default)
+				assert.Equal(t, "Deny", rules[1].Access)
+				assert.Equal(t, []string{subnetID}, rules[1].SubnetIDs)
+			},
+		},
+		{
+			name:   "groups without id or properties are skipped",
+			groups: []*network.SecurityGroup{{}, {ID: to(nsgID)}},
+			expect: func(t *testing.T, rules []*SecurityRule, err error) {
+				assert.NoError(t, err)
+				assert.Empty(t, rules)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rules []*SecurityRule
+			err := ListSecurityRules(context.Background(), tt.groups, func(r *SecurityRule) error {
+				rules = append(rules, r)
+				return nil
+			})
+			tt.expect(t, rules, err)
+		})
+	}
+}
+
+func TestScrapper_NetworkInventory(t *testing.T) {
+	vnetID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/vnet"
+	nsgs := scrappertest.NewNetworkSecurityGroupPager(scrappertest.Items(&network.SecurityGroup{
+		ID: to("nsg"),
+		Properties: &network.SecurityGroupPropertiesFormat{
+			SecurityRules: []*network.SecurityRule{{Name: to("a")}, {Name: to("b")}},
+		},
+	}))
+	vnets := scrappertest.NewVirtualNetworkPager(scrappertest.Items(&network.VirtualNetwork{
+		ID: to(vnetID),
+		Properties: &network.VirtualNetworkPropertiesFormat{
+			Subnets: []*network.Subnet{{Name: to("default")}, {Name: to("aks")}},
+		},
+	}))
+	routes := scrappertest.NewRouteTablePager(scrappertest.Fail[network.RouteTable](errors.New("boom")))
+
+	sink := NewMemorySink()
+	s, err := NewScrapper(testCred(), "sub",
+		WithKinds(KindSubnet, KindSecurityRule, KindRouteTable),
+		WithNetworkSecurityGroupFactory(scrappertest.Factory[NetworkSecurityGroupPager](nsgs)),
+		WithVirtualNetworksFactory(scrappertest.Factory[VirtualNetworkPager](vnets)),
+		WithRouteTableFactory(scrappertest.Factory[RouteTablePager](routes)),
+		WithPartialResults(),
+		WithSink(sink),
+	)
+	require.NoError(t, err)
+	report, err := s.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, map[Kind]int{KindSubnet: 2, KindSecurityRule: 2}, countKinds(sink.Records()))
+	for _, r := range sink.Records() {
+		if subnet, ok := r.Payload.(*Subnet); ok {
+			assert.Equal(t, vnetID, subnet.VirtualNetworkID)
+		}
+	}
+	assert.ErrorContains(t, report.Kinds[KindRouteTable].Err, "boom")
+}
+
+func TestScrapper_SecurityRulesOfPartialGroups(t *testing.T) {
+	nsgs := scrappertest.NewNetworkSecurityGroupPager(
+		scrappertest.Items(&network.SecurityGroup{
+			ID: to("nsg"),
+			Properties: &network.SecurityGroupPropertiesFormat{
+				SecurityRules: []*network.SecurityRule{{Name: to("a")}},
+			},
+		}),
+		scrappertest.Fail[network.SecurityGroup](errors.New("boom")),
+	)
+
+	sink := NewMemorySink()
+	s, err := NewScrapper(testCred(), "sub", append(scrappertest.Empty(),
+		WithKinds(KindSecurityRule),
+		WithNetworkSecurityGroupFactory(scrappertest.Factory[NetworkSecurityGroupPager](nsgs)),
+		WithPartialResults(),
+		WithSink(sink),
+	)...)
+	require.NoError(t, err)
+	report, err := s.Run(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, sink.Records(), 1, "the rules of the groups listed before the failure are emitted")
+	assert.Equal(t, "a", sink.Records()[0].Payload.(*SecurityRule).Name)
+	assert.NoError(t, report.Kinds[KindSecurityRule].Err)
+	assert.ErrorContains(t, report.Kinds[KindNetworkSecurityGroup].Err, "boom")
+}
@@ -2,11 +2,10 @@ package scrapper_test
 
 import (
 	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
 	"context"
 	"errors"
 
-	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,37 +20,23 @@ func TestScrapper_ListNodePools(t *testing.T) {
 		expect                func(t *testing.T, err error)
 	}{
 		{
-			name: "node pool iteration succeeds",
-			nodePoolClientFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (NodePoolPager, error) {
-				return NewNodePager[container.AgentPoolsClientListOptions, container.AgentPoolsClientListResponse]{item: &container.AgentPoolsClientListResponse{
-					AgentPoolListResult: container.AgentPoolListResult{
-						Value: []*container.AgentPool{{}},
-					},
-				}}, nil
-			},
+			name:                  "node pool iteration succeeds",
+			nodePoolClientFactory: scrappertest.Factory[NodePoolPager](scrappertest.NewNodePoolPager(scrappertest.Items(&container.AgentPool{}))),
 			expect: func(t *testing.T, err error) {
 				assert.NoError(t, err)
 			},
 		},
 		{
-			name: "node pool handler fails",
-			nodePoolClientFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (NodePoolPager, error) {
-				return NewNodePager[container.AgentPoolsClientListOptions, container.AgentPoolsClientListResponse]{item: &container.AgentPoolsClientListResponse{
-					AgentPoolListResult: container.AgentPoolListResult{
-						Value: []*container.AgentPool{{}},
-					},
-				}}, nil
-			},
-			handlerError: errors.New("failed to Handle node pool"),
+			name:                  "node pool handler fails",
+			nodePoolClientFactory: scrappertest.Factory[NodePoolPager](scrappertest.NewNodePoolPager(scrappertest.Items(&container.AgentPool{}))),
+			handlerError:          errors.New("failed to Handle node pool"),
 			expect: func(t *testing.T, err error) {
 				assert.Error(t, err)
 			},
 		},
 		{
-			name: "node pool iteration fails",
-			nodePoolClientFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (NodePoolPager, error) {
-				return FailNodePager[container.AgentPoolsClientListOptions, container.AgentPoolsClientListResponse]{}, nil
-			},
+			name:                  "node pool iteration fails",
+			nodePoolClientFactory: scrappertest.Factory[NodePoolPager](scrappertest.NewNodePoolPager(scrappertest.Fail[container.AgentPool](errors.New("failed to iterate")))),
 			expect: func(t *testing.T, err error) {
 				assert.Error(t, err)
 			},
@@ -65,3 +50,53 @@ func TestScrapper_ListNodePools(t *testing.T) {
 		})
 	}
 }
+
+func TestScrapper_ListClusterNodePools(t *testing.T) {
+	clusterID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/aks"
+	tests := []struct {
+		name                  string
+		clusters              []*container.ManagedCluster
+		nodePoolClientFactory NodePoolClientFactory
+		expect                func(t *testing.T, pools []*NodePool, err error)
+	}{
+		{
+			name:                  "node pools carry parent cluster id",
+			clusters:              []*container.ManagedCluster{{ID: &clusterID}, {}},
+			nodePoolClientFactory: scrappertest.Factory[NodePoolPager](scrappertest.NewNodePoolPager(scrappertest.Items(&container.AgentPool{}, &container.AgentPool{}))),
+			expect: func(t *testing.T, pools []*NodePool, err error) {
+				require.NoError(t, err)
+				require.Len(t, pools, 2)
+				assert.Equal(t, clusterID, pools[0].ClusterID)
+				assert.Equal(t, clusterID, pools[1].ClusterID)
+			},
+		},
+		{
+			name:                  "invalid cluster id fails",
+			clusters:              []*container.ManagedCluster{{ID: to("not-an-id")}},
+			nodePoolClientFactory: scrappertest.Factory[NodePoolPager](scrappertest.NewNodePoolPager()),
+			expect: func(t *testing.T, pools []*NodePool, err error) {
+				assert.Error(t, err)
+			},
+		},
+		{
+			name:                  "node pool iteration fails",
+			clusters:              []*container.ManagedCluster{{ID: &clusterID}},
+			nodePoolClientFactory: scrappertest.Factory[NodePoolPager](scrappertest.NewNodePoolPager(scrappertest.Fail[container.AgentPool](errors.New("failed to iterate")))),
+			expect: func(t *testing.T, pools []*NodePool, err error) {
+				assert.Error(t, err)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scraper, err := NewScrapper(testCred(), "not-important", WithNodePoolFactory(tt.nodePoolClientFactory), WithNodePoolConcurrency(1))
+			require.NoError(t, err)
+			var pools []*NodePool
+			err = scraper.ListClusterNodePools(context.Background(), tt.clusters, func(r *NodePool) error {
+				pools = append(pools, r)
+				return nil
+			})
+			tt.expect(t, pools, err)
+		})
+	}
+}
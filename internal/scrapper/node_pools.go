@@ -7,29 +7,66 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	"golang.org/x/sync/errgroup"
 )
 
+const KindNodePool Kind = "nodePool"
+
 // NodePoolPager used to scrape node pool information
 type NodePoolPager interface {
 	NewListPager(resourceGroupName string, resourceName string, options *container.AgentPoolsClientListOptions) *rt.Pager[container.AgentPoolsClientListResponse]
 }
 
-type NodePoolClientFactory func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (NodePoolPager, error)
+type NodePoolClientFactory = ClientFactory[NodePoolPager]
+
+func init() {
+	Register(Collector[NodePoolPager]{
+		Kind:      KindNodePool,
+		DependsOn: []Kind{KindCluster},
+		Factory: func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (NodePoolPager, error) {
+			return container.NewAgentPoolsClient(subscriptionID, credential, options)
+		},
+		Collect: func(ctx context.Context, c *Collection, _ NodePoolPager) error {
+			clusters := Collected[container.ManagedCluster](c, KindCluster)
+			return c.Scrapper().ListClusterNodePools(ctx, clusters, emitHandler[NodePool](c, KindNodePool))
+		},
+	})
+}
+
+func WithNodePoolFactory(f NodePoolClientFactory) OptionsFunc {
+	return WithFactory(KindNodePool, f)
+}
 
-func defaultNodePoolClientFactory(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (NodePoolPager, error) {
-	return container.NewAgentPoolsClient(subscriptionID, credential, options)
+// NodePool is an agent pool together with the id of the managed cluster that owns it.
+type NodePool struct {
+	ClusterID string               `json:"clusterId"`
+	AgentPool *container.AgentPool `json:"agentPool"`
 }
 
 func (s *Scrapper) ListNodePool(ctx context.Context, rg string, name string, pageHandler pageHandler[container.AgentPool]) error {
-	pager := s.nodePoolClient.NewListPager(rg, name, nil)
-	for pager.More() {
-		page, err := pager.NextPage(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to advance page: %w", err)
+	pager := clientFor[NodePoolPager](s, KindNodePool).NewListPager(rg, name, nil)
+	return listPages(ctx, pager, func(p container.AgentPoolsClientListResponse) []*container.AgentPool { return p.Value }, pageHandler)
+}
+
+// ListClusterNodePools lists the node pools of every cluster, using at most the configured concurrency of workers.
+func (s *Scrapper) ListClusterNodePools(ctx context.Context, clusters []*container.ManagedCluster, pageHandler pageHandler[NodePool]) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.kindConfig(KindNodePool).concurrency)
+	for _, cluster := range clusters {
+		if cluster.ID == nil {
+			continue
 		}
-		if err = processPage(page.Value, err, pageHandler); err != nil {
-			return err
+		id, err := arm.ParseResourceID(*cluster.ID)
+		if err != nil {
+			g.Go(func() error { return fmt.Errorf("failed to parse cluster id: %w", err) })
+			break
 		}
+		clusterID := *cluster.ID
+		g.Go(func() error {
+			return s.ListNodePool(ctx, id.ResourceGroupName, id.Name, func(r *container.AgentPool) error {
+				return pageHandler(&NodePool{ClusterID: clusterID, AgentPool: r})
+			})
+		})
 	}
-	return nil
+	return g.Wait()
 }
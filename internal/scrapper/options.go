@@ -1,61 +1,269 @@
 package scrapper
 
-// Options holds factory methods used to create clients that are passed to the scrapper.
+import (
+	"reflect"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+// Options holds the factory overrides used to create the clients of the registered collectors,
+// as well as the sink the scraped records are written to.
 type Options struct {
-	resourceGroupClientFactory     ResourceGroupClientFactory
-	providersClientFactory         ProvidersClientFactory
-	virtualNetworkClientFactory    VirtualNetworkClientFactory
-	diskEncryptionSetClientFactory DiskEncryptionSetClientFactory
-	clusterClientFactory           ClusterClientFactory
-	nodePoolClientFactory          NodePoolClientFactory
+	factories                 map[Kind]any
+	clientOptions             *arm.ClientOptions
+	kindClientOptions         map[Kind]*arm.ClientOptions
+	perCallPolicies           []policy.Policy
+	perRetryPolicies          []policy.Policy
+	cloud                     *cloud.Configuration
+	transport                 policy.Transporter
+	throttling                *ThrottleOptions
+	throttle                  *Throttle
+	kindConfigs               map[Kind]kindConfig
+	sink                      Sink
+	subscriptionClientFactory SubscriptionClientFactory
+	subscriptionConcurrency   int
+	timeout                   time.Duration
+	kindTimeouts              map[Kind]time.Duration
+	partial                   bool
+	include                   []Kind
+	exclude                   []Kind
+	scope                     *ResourceGroupScope
+	backend                   Backend
+	graphClientFactory        ResourceGraphClientFactory
+	graphResults              graphResults
 }
 
-// DefaultOptions initialize scrapper to user the default client factories from the azure-go-sdk.
+const (
+	defaultKindConcurrency         = 5
+	defaultSubscriptionConcurrency = 4
+)
+
+// workers returns n, or def when n would not start a single worker.
+func workers(n int, def int) int {
+	if n < 1 {
+		return def
+	}
+	return n
+}
+
+// kindConfig holds the settings of a single kind, read by the collector of that kind.
+type kindConfig struct {
+	// concurrency limits how many resources the collector reads one by one at the same time.
+	concurrency int
+	// expand asks for the properties azure leaves out of listings, such as instance views.
+	expand bool
+}
+
+// configureKind updates the settings of the collectors of kinds.
+func (o *Options) configureKind(fn func(c *kindConfig), kinds ...Kind) {
+	for _, kind := range kinds {
+		c := o.kindConfigs[kind]
+		fn(&c)
+		o.kindConfigs[kind] = c
+	}
+}
+
+// DefaultOptions initialize scrapper to user the default client factories of the registered collectors.
 func DefaultOptions() *Options {
 	return &Options{
-		resourceGroupClientFactory:     defaultResourceGroupFactory,
-		providersClientFactory:         defaultProvidersClientFactory,
-		virtualNetworkClientFactory:    defaultNetworkClientFactory,
-		diskEncryptionSetClientFactory: defaultDiskEncryptionSetClientFactory,
-		clusterClientFactory:           defaultClusterClientFactory,
-		nodePoolClientFactory:          defaultNodePoolClientFactory,
+		factories:                 map[Kind]any{},
+		kindClientOptions:         map[Kind]*arm.ClientOptions{},
+		kindConfigs:               map[Kind]kindConfig{},
+		sink:                      NewStdoutSink(),
+		subscriptionClientFactory: defaultSubscriptionClientFactory,
+		subscriptionConcurrency:   defaultSubscriptionConcurrency,
+		backend:                   BackendARM,
+		graphClientFactory:        defaultResourceGraphClientFactory,
+		timeout:                   30 * time.Second,
+		kindTimeouts:              map[Kind]time.Duration{},
 	}
 }
 
 type OptionsFunc func(opt *Options)
 
-func WithResourceGroupsFactory(f ResourceGroupClientFactory) OptionsFunc {
+// resolveOptions applies the option functions on top of the default options.
+func resolveOptions(opts ...OptionsFunc) *Options {
+	o := DefaultOptions()
+	for _, fn := range opts {
+		fn(o)
+	}
+	return o
+}
+
+// useThrottle adds the policy of the configured throttle to the pipeline of every client,
+// creating a throttle from the throttling options unless a shared one is set.
+func (o *Options) useThrottle() {
+	t := o.throttle
+	if t == nil && o.throttling != nil {
+		t = NewThrottle(*o.throttling)
+	}
+	if t != nil {
+		o.perRetryPolicies = append(o.perRetryPolicies, t.Policy())
+	}
+}
+
+// baseClientOptions returns a copy of the client options shared by every client, including the custom policies and cloud.
+// It returns nil when no client options are configured so the sdk defaults apply.
+func (o *Options) baseClientOptions() *arm.ClientOptions {
+	return o.withPolicies(o.clientOptions)
+}
+
+// clientOptionsFor returns a copy of the client options used to create the client of kind,
+// the fields set in the kind specific options override the shared ones and the custom policies are appended.
+func (o *Options) clientOptionsFor(kind Kind) *arm.ClientOptions {
+	if opts := o.kindClientOptions[kind]; opts != nil {
+		return o.withPolicies(mergeClientOptions(o.clientOptions, opts))
+	}
+	return o.baseClientOptions()
+}
+
+// mergeClientOptions returns a copy of base with the fields set in override replacing its own,
+// the policies of override run after those of base.
+func mergeClientOptions(base *arm.ClientOptions, override *arm.ClientOptions) *arm.ClientOptions {
+	merged := base.Clone()
+	if merged == nil {
+		merged = &arm.ClientOptions{}
+	}
+	set := func(v any) bool { return !reflect.ValueOf(v).IsZero() }
+	if override.APIVersion != "" {
+		merged.APIVersion = override.APIVersion
+	}
+	if set(override.Cloud) {
+		merged.Cloud = override.Cloud
+	}
+	if set(override.Logging) {
+		merged.Logging = override.Logging
+	}
+	if set(override.Retry) {
+		merged.Retry = override.Retry
+	}
+	if set(override.Telemetry) {
+		merged.Telemetry = override.Telemetry
+	}
+	if set(override.TracingProvider) {
+		merged.TracingProvider = override.TracingProvider
+	}
+	if override.Transport != nil {
+		merged.Transport = override.Transport
+	}
+	if override.AuxiliaryTenants != nil {
+		merged.AuxiliaryTenants = override.AuxiliaryTenants
+	}
+	if override.DisableRPRegistration {
+		merged.DisableRPRegistration = true
+	}
+	merged.PerCallPolicies = append(merged.PerCallPolicies, override.PerCallPolicies...)
+	merged.PerRetryPolicies = append(merged.PerRetryPolicies, override.PerRetryPolicies...)
+	return merged
+}
+
+func (o *Options) withPolicies(opts *arm.ClientOptions) *arm.ClientOptions {
+	if opts == nil && len(o.perCallPolicies) == 0 && len(o.perRetryPolicies) == 0 && o.cloud == nil && o.transport == nil {
+		return nil
+	}
+
+	opts = opts.Clone()
+	if opts == nil {
+		opts = &arm.ClientOptions{}
+	}
+	opts.PerCallPolicies = append(opts.PerCallPolicies, o.perCallPolicies...)
+	opts.PerRetryPolicies = append(opts.PerRetryPolicies, o.perRetryPolicies...)
+	if o.cloud != nil {
+		opts.Cloud = *o.cloud
+	}
+	if o.transport != nil {
+		opts.Transport = o.transport
+	}
+	return opts
+}
+
+// WithClientOptions sets the client options every client is created with,
+// such as the retry policy, transport, telemetry application id or cloud.
+func WithClientOptions(opts *arm.ClientOptions) OptionsFunc {
+	return func(opt *Options) {
+		opt.clientOptions = opts
+	}
+}
+
+// WithKindClientOptions sets client options for the client of a single kind, the fields it sets override those of the
+// shared client options and its policies are added to theirs. This is mostly useful to override the api version of a
+// single resource provider.
+func WithKindClientOptions(kind Kind, opts *arm.ClientOptions) OptionsFunc {
+	return func(opt *Options) {
+		opt.kindClientOptions[kind] = opts
+	}
+}
+
+// WithTransport sets the transport every client sends its requests through, replacing the transport of the client options.
+func WithTransport(t policy.Transporter) OptionsFunc {
+	return func(opt *Options) {
+		opt.transport = t
+	}
+}
+
+// WithPerCallPolicies adds policies that run once per request to the pipeline of every client.
+func WithPerCallPolicies(policies ...policy.Policy) OptionsFunc {
+	return func(opt *Options) {
+		opt.perCallPolicies = append(opt.perCallPolicies, policies...)
+	}
+}
+
+// WithPerRetryPolicies adds policies that run for every attempt of a request to the pipeline of every client.
+func WithPerRetryPolicies(policies ...policy.Policy) OptionsFunc {
+	return func(opt *Options) {
+		opt.perRetryPolicies = append(opt.perRetryPolicies, policies...)
+	}
+}
+
+// WithNodePoolConcurrency limits how many clusters have their node pools listed at the same time,
+// values below 1 keep the default of 5.
+func WithNodePoolConcurrency(n int) OptionsFunc {
+	return func(opt *Options) {
+		opt.configureKind(func(c *kindConfig) { c.concurrency = n }, KindNodePool)
+	}
+}
+
+// WithSink sets where scraped records are written, by default records are written to stdout.
+func WithSink(sink Sink) OptionsFunc {
 	return func(opt *Options) {
-		opt.resourceGroupClientFactory = f
+		opt.sink = sink
 	}
 }
 
-func WithProvidersFactory(f ProvidersClientFactory) OptionsFunc {
+func WithSubscriptionsFactory(f SubscriptionClientFactory) OptionsFunc {
 	return func(opt *Options) {
-		opt.providersClientFactory = f
+		opt.subscriptionClientFactory = f
 	}
 }
 
-func WithVirtualNetworksFactory(f VirtualNetworkClientFactory) OptionsFunc {
+// WithSubscriptionConcurrency limits how many subscriptions a MultiScrapper scrapes at the same time,
+// values below 1 keep the default of 4.
+func WithSubscriptionConcurrency(n int) OptionsFunc {
 	return func(opt *Options) {
-		opt.virtualNetworkClientFactory = f
+		opt.subscriptionConcurrency = workers(n, defaultSubscriptionConcurrency)
 	}
 }
 
-func WithDiskEncryptionSetFactory(f DiskEncryptionSetClientFactory) OptionsFunc {
+// WithTimeout bounds a whole scrape, a zero duration leaves the scrape bounded only by the caller context.
+func WithTimeout(d time.Duration) OptionsFunc {
 	return func(opt *Options) {
-		opt.diskEncryptionSetClientFactory = f
+		opt.timeout = d
 	}
 }
 
-func WithClusterFactory(f ClusterClientFactory) OptionsFunc {
+// WithKindTimeout bounds the scrape of a single resource kind.
+func WithKindTimeout(kind Kind, d time.Duration) OptionsFunc {
 	return func(opt *Options) {
-		opt.clusterClientFactory = f
+		opt.kindTimeouts[kind] = d
 	}
 }
 
-func WithNodePoolFactory(f NodePoolClientFactory) OptionsFunc {
+// WithPartialResults keeps scraping the remaining kinds when one fails, failures are recorded in the RunReport.
+func WithPartialResults() OptionsFunc {
 	return func(opt *Options) {
-		opt.nodePoolClientFactory = f
+		opt.partial = true
 	}
 }
@@ -0,0 +1,180 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type loggingPolicy struct{}
+
+func (loggingPolicy) Do(req *policy.Request) (*http.Response, error) {
+	return req.Next()
+}
+
+func TestClientOptions(t *testing.T) {
+	transport := &http.Client{}
+	base := &arm.ClientOptions{ClientOptions: policy.ClientOptions{
+		Telemetry: policy.TelemetryOptions{ApplicationID: "az-scrapper"},
+		Retry:     policy.RetryOptions{MaxRetries: 5},
+		Transport: transport,
+	}}
+	override := &arm.ClientOptions{ClientOptions: policy.ClientOptions{APIVersion: "2021-04-01"}}
+	logging := loggingPolicy{}
+
+	tests := []struct {
+		name    string
+		options []OptionsFunc
+		want    func(t *testing.T, providers *arm.ClientOptions, clusters *arm.ClientOptions)
+	}{
+		{
+			name: "defaults to sdk options",
+			want: func(t *testing.T, providers *arm.ClientOptions, clusters *arm.ClientOptions) {
+				assert.Nil(t, providers)
+				assert.Nil(t, clusters)
+			},
+		},
+		{
+			name:    "shared options reach every client",
+			options: []OptionsFunc{WithClientOptions(base)},
+			want: func(t *testing.T, providers *arm.ClientOptions, clusters *arm.ClientOptions) {
+				assert.Equal(t, "az-scrapper", providers.Telemetry.ApplicationID)
+				assert.Equal(t, "az-scrapper", clusters.Telemetry.ApplicationID)
+				assert.NotSame(t, base, providers)
+			},
+		},
+		{
+			name:    "kind options override the shared options they set",
+			options: []OptionsFunc{WithClientOptions(base), WithKindClientOptions(KindProvider, override)},
+			want: func(t *testing.T, providers *arm.ClientOptions, clusters *arm.ClientOptions) {
+				assert.Equal(t, "2021-04-01", providers.APIVersion)
+				assert.Same(t, transport, providers.Transport)
+				assert.Equal(t, int32(5), providers.Retry.MaxRetries)
+				assert.Equal(t, "az-scrapper", providers.Telemetry.ApplicationID)
+				assert.Empty(t, clusters.APIVersion)
+				assert.Empty(t, base.APIVersion)
+			},
+		},
+		{
+			name:    "custom policies are added to every client",
+			options: []OptionsFunc{WithKindClientOptions(KindProvider, override), WithPerCallPolicies(logging), WithPerRetryPolicies(logging)},
+			want: func(t *testing.T, providers *arm.ClientOptions, clusters *arm.ClientOptions) {
+				assert.Equal(t, []policy.Policy{logging}, providers.PerCallPolicies)
+				assert.Equal(t, []policy.Policy{logging}, providers.PerRetryPolicies)
+				assert.Equal(t, []policy.Policy{logging}, clusters.PerCallPolicies)
+				assert.Empty(t, override.PerCallPolicies)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var providers, clusters *arm.ClientOptions
+			options := append(tt.options,
+				WithProvidersFactory(func(_ string, _ az.TokenCredential, opts *arm.ClientOptions) (ProvidersPager, error) {
+					providers = opts
+					return scrappertest.NewProvidersPager(), nil
+				}),
+				WithClusterFactory(func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (ClusterPager, error) {
+					clusters = opts
+					return nil, nil
+				}),
+			)
+			_, err := NewScrapper(testCred(), "sub", options...)
+			require.NoError(t, err)
+			tt.want(t, providers, clusters)
+		})
+	}
+}
+
+func TestConcurrencyOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options func(n int) []OptionsFunc
+		run     func(ctx context.Context, s *Scrapper) (int, error)
+	}{
+		{
+			name: "node pools",
+			options: func(n int) []OptionsFunc {
+				pools := scrappertest.NewNodePoolPager().Cluster("rg", "aks", scrappertest.Items(&container.AgentPool{}))
+				return []OptionsFunc{WithNodePoolFactory(scrappertest.Factory[NodePoolPager](pools)), WithNodePoolConcurrency(n)}
+			},
+			run: func(ctx context.Context, s *Scrapper) (int, error) {
+				var pools int
+				err := s.ListClusterNodePools(ctx, []*container.ManagedCluster{{ID: to(armClusterID("aks"))}}, func(*NodePool) error {
+					pools++
+					return nil
+				})
+				return pools, err
+			},
+		},
+		{
+			name: "virtual machine instance views",
+			options: func(n int) []OptionsFunc {
+				vms := scrappertest.NewVirtualMachinePager(scrappertest.Items(&compute.VirtualMachine{
+					ID: to("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm"),
+				}))
+				return []OptionsFunc{WithVirtualMachineFactory(scrappertest.Factory[VirtualMachinePager](vms)), WithInstanceView(), WithComputeConcurrency(n)}
+			},
+			run: func(ctx context.Context, s *Scrapper) (int, error) {
+				var vms int
+				err := s.ListVirtualMachines(ctx, func(*VirtualMachine) error {
+					vms++
+					return nil
+				})
+				return vms, err
+			},
+		},
+		{
+			name: "scale set instances",
+			options: func(n int) []OptionsFunc {
+				instances := scrappertest.NewScaleSetInstancePager().ScaleSet("rg", "vmss", scrappertest.Items(&compute.VirtualMachineScaleSetVM{}))
+				return []OptionsFunc{
+					WithKinds(KindScaleSetInstance),
+					WithScaleSetInstanceFactory(scrappertest.Factory[ScaleSetInstancePager](instances)),
+					WithComputeConcurrency(n),
+				}
+			},
+			run: func(ctx context.Context, s *Scrapper) (int, error) {
+				var found int
+				scaleSet := &compute.VirtualMachineScaleSet{ID: to("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachineScaleSets/vmss")}
+				err := s.ListScaleSetInstances(ctx, []*ScaleSet{{ScaleSet: scaleSet}}, func(*ScaleSetInstance) error {
+					found++
+					return nil
+				})
+				return found, err
+			},
+		},
+	}
+	for _, tt := range tests {
+		for _, n := range []int{0, -1} {
+			t.Run(tt.name, func(t *testing.T) {
+				s, err := NewScrapper(testCred(), "sub", tt.options(n)...)
+				require.NoError(t, err)
+
+				done := make(chan struct{})
+				go func() {
+					defer close(done)
+					found, err := tt.run(context.Background(), s)
+					assert.NoError(t, err)
+					assert.Equal(t, 1, found)
+				}()
+				select {
+				case <-done:
+				case <-time.After(5 * time.Second):
+					t.Fatalf("scrape with a concurrency of %d never completed", n)
+				}
+			})
+		}
+	}
+}
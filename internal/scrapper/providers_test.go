@@ -2,11 +2,10 @@ package scrapper_test
 
 import (
 	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
 	"context"
 	"errors"
 
-	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,37 +20,23 @@ func TestScrapper_ListProviders(t *testing.T) {
 		want             func(t *testing.T, err error)
 	}{
 		{
-			name: "provider iteration succeeds",
-			providersFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (ProvidersPager, error) {
-				return NewPager[resource.ProvidersClientListOptions, resource.ProvidersClientListResponse]{item: &resource.ProvidersClientListResponse{
-					ProviderListResult: resource.ProviderListResult{
-						Value: []*resource.Provider{{}},
-					},
-				}}, nil
-			},
+			name:             "provider iteration succeeds",
+			providersFactory: scrappertest.Factory[ProvidersPager](scrappertest.NewProvidersPager(scrappertest.Items(&resource.Provider{}))),
 			want: func(t *testing.T, err error) {
 				assert.NoError(t, err)
 			},
 		},
 		{
-			name: "provider handler fails",
-			providersFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (ProvidersPager, error) {
-				return NewPager[resource.ProvidersClientListOptions, resource.ProvidersClientListResponse]{item: &resource.ProvidersClientListResponse{
-					ProviderListResult: resource.ProviderListResult{
-						Value: []*resource.Provider{{}},
-					},
-				}}, nil
-			},
-			handlerError: errors.New("failed to Handle provider"),
+			name:             "provider handler fails",
+			providersFactory: scrappertest.Factory[ProvidersPager](scrappertest.NewProvidersPager(scrappertest.Items(&resource.Provider{}))),
+			handlerError:     errors.New("failed to Handle provider"),
 			want: func(t *testing.T, err error) {
 				assert.Error(t, err)
 			},
 		},
 		{
-			name: "provider iteration fails",
-			providersFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (ProvidersPager, error) {
-				return FailPager[resource.ProvidersClientListOptions, resource.ProvidersClientListResponse]{}, nil
-			},
+			name:             "provider iteration fails",
+			providersFactory: scrappertest.Factory[ProvidersPager](scrappertest.NewProvidersPager(scrappertest.Fail[resource.Provider](errors.New("failed to iterate")))),
 			want: func(t *testing.T, err error) {
 				assert.Error(t, err)
 			},
@@ -0,0 +1,17 @@
+package scrapper
+
+import (
+	"time"
+)
+
+// Kind identifies the type of resource carried by a Record, every registered Collector emits its own kind.
+type Kind string
+
+// Record is the envelope every scraped resource is wrapped in before it is written to a sink.
+type Record struct {
+	Kind           Kind      `json:"kind"`
+	SubscriptionID string    `json:"subscriptionId"`
+	RunID          string    `json:"runId"`
+	ScrapedAt      time.Time `json:"scrapedAt"`
+	Payload        any       `json:"payload"`
+}
@@ -0,0 +1,184 @@
+package scrapper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RunReport summarises the outcome of scraping a single subscription.
+type RunReport struct {
+	SubscriptionID string
+	RunID          string
+	StartedAt      time.Time
+	Duration       time.Duration
+	Kinds          map[Kind]*KindReport
+}
+
+// KindReport holds the outcome of scraping a single resource kind.
+type KindReport struct {
+	mu       sync.Mutex
+	Kind     Kind
+	Records  int
+	Pages    int
+	Duration time.Duration
+	Err      error
+}
+
+func newRunReport(sub string, runID string, kinds ...Kind) *RunReport {
+	r := &RunReport{
+		SubscriptionID: sub,
+		RunID:          runID,
+		StartedAt:      time.Now().UTC(),
+		Kinds:          make(map[Kind]*KindReport, len(kinds)),
+	}
+	for _, kind := range kinds {
+		r.Kinds[kind] = &KindReport{Kind: kind}
+	}
+	return r
+}
+
+// Failed reports whether any resource kind failed to scrape.
+func (r *RunReport) Failed() bool {
+	return r.Err() != nil
+}
+
+// Err joins the errors of every failed resource kind, ordered by kind.
+func (r *RunReport) Err() error {
+	var errs []error
+	for _, kr := range r.sortedKinds() {
+		if err := kr.error(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r *RunReport) sortedKinds() []*KindReport {
+	kinds := make([]*KindReport, 0, len(r.Kinds))
+	for _, kr := range r.Kinds {
+		kinds = append(kinds, kr)
+	}
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].Kind < kinds[j].Kind })
+	return kinds
+}
+
+// runReportJSON is the wire format of a RunReport, durations are rendered as strings and kinds as an ordered list.
+type runReportJSON struct {
+	SubscriptionID string        `json:"subscriptionId"`
+	RunID          string        `json:"runId"`
+	StartedAt      time.Time     `json:"startedAt"`
+	Duration       string        `json:"duration"`
+	Kinds          []*KindReport `json:"kinds"`
+}
+
+// kindReportJSON is the wire format of a KindReport.
+type kindReportJSON struct {
+	Kind     Kind   `json:"kind"`
+	Records  int    `json:"records"`
+	Pages    int    `json:"pages"`
+	Duration string `json:"duration"`
+	Error    string `json:"error,omitempty"`
+}
+
+func (r *RunReport) MarshalJSON() ([]byte, error) {
+	return json.Marshal(runReportJSON{
+		SubscriptionID: r.SubscriptionID,
+		RunID:          r.RunID,
+		StartedAt:      r.StartedAt,
+		Duration:       r.Duration.String(),
+		Kinds:          r.sortedKinds(),
+	})
+}
+
+func (r *RunReport) UnmarshalJSON(data []byte) error {
+	var v runReportJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	d, err := time.ParseDuration(v.Duration)
+	if err != nil {
+		return err
+	}
+
+	r.SubscriptionID, r.RunID, r.StartedAt, r.Duration = v.SubscriptionID, v.RunID, v.StartedAt, d
+	r.Kinds = make(map[Kind]*KindReport, len(v.Kinds))
+	for _, kr := range v.Kinds {
+		r.Kinds[kr.Kind] = kr
+	}
+	return nil
+}
+
+func (k *KindReport) MarshalJSON() ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	var msg string
+	if k.Err != nil {
+		msg = k.Err.Error()
+	}
+	return json.Marshal(kindReportJSON{
+		Kind:     k.Kind,
+		Records:  k.Records,
+		Pages:    k.Pages,
+		Duration: k.Duration.String(),
+		Error:    msg,
+	})
+}
+
+func (k *KindReport) UnmarshalJSON(data []byte) error {
+	var v kindReportJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	d, err := time.ParseDuration(v.Duration)
+	if err != nil {
+		return err
+	}
+
+	k.Kind, k.Records, k.Pages, k.Duration, k.Err = v.Kind, v.Records, v.Pages, d, nil
+	if v.Error != "" {
+		k.Err = errors.New(v.Error)
+	}
+	return nil
+}
+
+func (k *KindReport) addRecord() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.Records++
+}
+
+func (k *KindReport) addPage() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.Pages++
+}
+
+func (k *KindReport) finish(d time.Duration, err error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.Duration = d
+	k.Err = err
+}
+
+func (k *KindReport) error() error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.Err
+}
+
+type kindReportKey struct{}
+
+// withKindReport attaches the report that pages fetched through ctx are counted against.
+func withKindReport(ctx context.Context, k *KindReport) context.Context {
+	return context.WithValue(ctx, kindReportKey{}, k)
+}
+
+func kindReportFromContext(ctx context.Context) *KindReport {
+	k, _ := ctx.Value(kindReportKey{}).(*KindReport)
+	return k
+}
@@ -0,0 +1,46 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunReport_MarshalJSON(t *testing.T) {
+	report := &RunReport{
+		SubscriptionID: "sub",
+		RunID:          "run",
+		StartedAt:      time.Date(2023, 10, 1, 0, 0, 0, 0, time.UTC),
+		Duration:       2 * time.Second,
+		Kinds: map[Kind]*KindReport{
+			KindProvider: {Kind: KindProvider, Records: 3, Pages: 1, Duration: time.Second},
+			KindCluster:  {Kind: KindCluster, Duration: time.Second, Err: errors.New("boom")},
+		},
+	}
+
+	content, err := json.Marshal(report)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"subscriptionId": "sub",
+		"runId": "run",
+		"startedAt": "2023-10-01T00:00:00Z",
+		"duration": "2s",
+		"kinds": [
+			{"kind": "managedCluster", "records": 0, "pages": 0, "duration": "1s", "error": "boom"},
+			{"kind": "provider", "records": 3, "pages": 1, "duration": "1s"}
+		]
+	}`, string(content))
+	assert.True(t, report.Failed())
+	assert.EqualError(t, report.Err(), "boom")
+
+	var decoded RunReport
+	require.NoError(t, json.Unmarshal(content, &decoded))
+	assert.Equal(t, report.Duration, decoded.Duration)
+	assert.Equal(t, 3, decoded.Kinds[KindProvider].Records)
+	assert.EqualError(t, decoded.Kinds[KindCluster].Err, "boom")
+}
@@ -0,0 +1,255 @@
+package scrapper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	graph "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+)
+
+// Backend is where the scrapper lists resources from.
+type Backend string
+
+const (
+	// BackendARM lists every kind through the resource manager api of its resource provider.
+	BackendARM Backend = "arm"
+	// BackendResourceGraph lists the kinds that have a GraphQuery with Azure Resource Graph, the other kinds still
+	// go through ARM. A query covers many subscriptions at once, which is faster and throttles less on large tenants.
+	BackendResourceGraph Backend = "resourceGraph"
+)
+
+const (
+	// graphBatchSize is the most subscriptions a resource graph query can cover.
+	graphBatchSize = 1000
+	// graphPageSize is the most rows a resource graph page can hold.
+	graphPageSize = 1000
+)
+
+// ParseBackend validates a backend name, an empty name is the ARM backend.
+func ParseBackend(name string) (Backend, error) {
+	switch Backend(name) {
+	case "", BackendARM:
+		return BackendARM, nil
+	case BackendResourceGraph:
+		return BackendResourceGraph, nil
+	}
+	return "", fmt.Errorf("unknown backend %q", name)
+}
+
+// ResourceGraphClient used to run resource graph queries
+type ResourceGraphClient interface {
+	Resources(ctx context.Context, query graph.QueryRequest, options *graph.ClientResourcesOptions) (graph.ClientResourcesResponse, error)
+}
+
+type ResourceGraphClientFactory func(credential az.TokenCredential, options *arm.ClientOptions) (ResourceGraphClient, error)
+
+func defaultResourceGraphClientFactory(credential az.TokenCredential, options *arm.ClientOptions) (ResourceGraphClient, error) {
+	return graph.NewClient(credential, options)
+}
+
+// WithBackend selects where resources are listed from, BackendARM by default.
+func WithBackend(backend Backend) OptionsFunc {
+	return func(opt *Options) {
+		opt.backend = backend
+	}
+}
+
+func WithResourceGraphFactory(f ResourceGraphClientFactory) OptionsFunc {
+	return func(opt *Options) {
+		opt.graphClientFactory = f
+	}
+}
+
+// GraphQuery lists the resources of a kind from Azure Resource Graph.
+type GraphQuery struct {
+	// Query is the KQL query of the resources, each row is a resource as ARM lists it along with its subscriptionId
+	// and resourceGroup.
+	Query string
+	// Collect emits the resources of a page of rows, every row belongs to the subscription of the collection.
+	Collect func(ctx context.Context, c *Collection, rows []json.RawMessage) error
+	// NeedsARM, when set, reports whether the options ask for fields the rows do not hold, the kind is then listed
+	// through ARM even with BackendResourceGraph.
+	NeedsARM func(o *Options) bool
+}
+
+// graphKinds returns the kinds listed from resource graph, the ones with a GraphQuery unless the options need ARM.
+// It returns nil with the ARM backend.
+func (o *Options) graphKinds(kinds []Kind) []Kind {
+	if o.backend != BackendResourceGraph {
+		return nil
+	}
+	var graphKinds []Kind
+	for _, kind := range kinds {
+		c, ok := registered(kind)
+		if !ok || c.graph() == nil || (c.graph().NeedsARM != nil && c.graph().NeedsARM(o)) {
+			continue
+		}
+		graphKinds = append(graphKinds, kind)
+	}
+	return graphKinds
+}
+
+// graphResources is the query of the resources of an ARM resource type.
+func graphResources(resourceType string) string {
+	return fmt.Sprintf("resources | where type =~ '%s'", resourceType)
+}
+
+// graphRows emits the rows of a page decoded as T, through the same page handler the ARM listing uses.
+func graphRows[T any](ctx context.Context, rows []json.RawMessage, pageHandler pageHandler[T]) error {
+	page := make([]*T, 0, len(rows))
+	for _, row := range rows {
+		v := new(T)
+		if err := json.Unmarshal(row, v); err != nil {
+			return fmt.Errorf("failed to decode resource graph row: %w", err)
+		}
+		page = append(page, v)
+	}
+	return processPage(ctx, page, pageHandler)
+}
+
+// graphRow is a resource graph row along with the columns the rows are dispatched by.
+type graphRow struct {
+	subscriptionID string
+	resourceGroup  string
+	raw            json.RawMessage
+}
+
+// graphResult holds the pages of rows of a kind for a single subscription, or why they could not be listed.
+type graphResult struct {
+	pages [][]graphRow
+	err   error
+}
+
+// graphResults are the results of every kind queried for a subscription.
+type graphResults map[Kind]*graphResult
+
+// queryGraph runs the query of every kind, over the subscriptions in batches of graphBatchSize, and splits the rows
+// by subscription. A failing query fails its kind for every subscription of the batch.
+func queryGraph(ctx context.Context, client ResourceGraphClient, kinds []Kind, subs []string) map[string]graphResults {
+	results := make(map[string]graphResults, len(subs))
+	for _, sub := range subs {
+		results[strings.ToLower(sub)] = graphResults{}
+	}
+	for _, kind := range kinds {
+		c, _ := registered(kind)
+		for start := 0; start < len(subs); start += graphBatchSize {
+			end := start + graphBatchSize
+			if end > len(subs) {
+				end = len(subs)
+			}
+			batch := subs[start:end]
+			for _, sub := range batch {
+				results[strings.ToLower(sub)][kind] = &graphResult{}
+			}
+			err := queryGraphPages(ctx, client, c.graph().Query, batch, func(rows []graphRow) {
+				page := map[string][]graphRow{}
+				for _, row := range rows {
+					sub := strings.ToLower(row.subscriptionID)
+					page[sub] = append(page[sub], row)
+				}
+				for sub, rows := range page {
+					if r, ok := results[sub][kind]; ok {
+						r.pages = append(r.pages, rows)
+					}
+				}
+			})
+			if err != nil {
+				for _, sub := range batch {
+					results[strings.ToLower(sub)][kind].err = fmt.Errorf("resource graph: %w", err)
+				}
+			}
+		}
+	}
+	return results
+}
+
+// queryGraphPages runs query over the subscriptions, following the skip token of every page.
+func queryGraphPages(ctx context.Context, client ResourceGraphClient, query string, subs []string, pageHandler func([]graphRow)) error {
+	subscriptions := make([]*string, 0, len(subs))
+	for _, sub := range subs {
+		sub := sub
+		subscriptions = append(subscriptions, &sub)
+	}
+	top := int32(graphPageSize)
+	format := graph.ResultFormatObjectArray
+	var skipToken *string
+	for {
+		resp, err := client.Resources(ctx, graph.QueryRequest{
+			Query:         &query,
+			Subscriptions: subscriptions,
+			Options:       &graph.QueryRequestOptions{ResultFormat: &format, Top: &top, SkipToken: skipToken},
+		}, nil)
+		if err != nil {
+			return fmt.Errorf("failed to advance page: %w", err)
+		}
+		rows, err := decodeGraphRows(resp.Data)
+		if err != nil {
+			return err
+		}
+		pageHandler(rows)
+		if resp.SkipToken == nil || *resp.SkipToken == "" {
+			return nil
+		}
+		skipToken = resp.SkipToken
+	}
+}
+
+// decodeGraphRows reads the rows of an object array result.
+func decodeGraphRows(data any) ([]graphRow, error) {
+	if data == nil {
+		return nil, nil
+	}
+	objects, ok := data.([]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected resource graph result of type %T", data)
+	}
+	rows := make([]graphRow, 0, len(objects))
+	for _, object := range objects {
+		raw, err := json.Marshal(object)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read resource graph row: %w", err)
+		}
+		var columns struct {
+			SubscriptionID string `json:"subscriptionId"`
+			ResourceGroup  string `json:"resourceGroup"`
+		}
+		if err = json.Unmarshal(raw, &columns); err != nil {
+			return nil, fmt.Errorf("failed to read resource graph row: %w", err)
+		}
+		rows = append(rows, graphRow{subscriptionID: columns.SubscriptionID, resourceGroup: columns.ResourceGroup, raw: raw})
+	}
+	return rows, nil
+}
+
+// collectGraph emits the rows listed for a kind, scoped scrapes drop the rows outside of the resource groups in scope.
+func (s *Scrapper) collectGraph(ctx context.Context, col *Collection, c registration, result *graphResult) error {
+	if result.err != nil {
+		return result.err
+	}
+	var inScope map[string]bool
+	if s.scope != nil && c.kind() != KindResourceGroup {
+		inScope = map[string]bool{}
+		for _, rg := range col.ResourceGroups() {
+			inScope[strings.ToLower(rg)] = true
+		}
+	}
+	for _, page := range result.pages {
+		rows := make([]json.RawMessage, 0, len(page))
+		for _, row := range page {
+			if inScope == nil || inScope[strings.ToLower(row.resourceGroup)] {
+				rows = append(rows, row.raw)
+			}
+		}
+		if len(rows) == 0 {
+			continue
+		}
+		if err := c.graph().Collect(ctx, col, rows); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,356 @@
+package scrapper_test
+
+import (
+	"azure-scrapper/internal/armfake"
+	. "azure-scrapper/internal/scrapper"
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	graph "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resourcegraph/armresourcegraph"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sync/errgroup"
+)
+
+func TestMultiScrapper_ResourceGraphBackend(t *testing.T) {
+	srv := armfake.NewServer()
+	defer srv.Close()
+	subs := []string{"sub-a", "sub-b"}
+	var groups, clusters, disks []armfake.GraphRow
+	for _, sub := range subs {
+		rg := resource.ResourceGroup{ID: to("/subscriptions/" + sub + "/resourceGroups/rg"), Name: to("rg")}
+		aks := cluster(sub, "rg", "aks")
+		disk := compute.Disk{
+			ID:         to("/subscriptions/" + sub + "/resourceGroups/rg/providers/Microsoft.Compute/disks/os"),
+			Properties: &compute.DiskProperties{Encryption: &compute.Encryption{Type: to(compute.EncryptionTypeEncryptionAtRestWithPlatformKey)}},
+		}
+		require.NoError(t, srv.List(armfake.ResourceGroupsPath(sub), 0, rg))
+		require.NoError(t, srv.List(armfake.ClustersPath(sub), 0, aks))
+		require.NoError(t, srv.List(armfake.AgentPoolsPath(sub, "rg", "aks"), 0, container.AgentPool{Name: to("system")}))
+		require.NoError(t, srv.List(armfake.DisksPath(sub), 0, disk))
+
+		rg.Type = to("microsoft.resources/subscriptions/resourcegroups")
+		aks.Type = to("microsoft.containerservice/managedclusters")
+		disk.Type = to("microsoft.compute/disks")
+		groups = append(groups, armfake.GraphRow{SubscriptionID: sub, ResourceGroup: "rg", Resource: rg})
+		clusters = append(clusters, armfake.GraphRow{SubscriptionID: sub, ResourceGroup: "rg", Resource: aks})
+		disks = append(disks, armfake.GraphRow{SubscriptionID: sub, ResourceGroup: "rg", Resource: disk})
+	}
+	require.NoError(t, srv.Graph("resourcecontainers", 1, groups...))
+	require.NoError(t, srv.Graph("resources", 1, append(clusters, disks...)...))
+
+	run := func(backend Backend) ([]*RunReport, []Record) {
+		sink := NewMemorySink()
+		m, err := NewMultiScrapper(armfake.Credential(), subs,
+			WithKinds(KindResourceGroup, KindCluster, KindNodePool, KindDisk, KindEncryptionFinding),
+			WithBackend(backend),
+			WithClientOptions(srv.ClientOptions()),
+			WithSink(sink),
+		)
+		require.NoError(t, err)
+		reports, err := m.Run(context.Background())
+		require.NoError(t, err)
+		return reports, sink.Records()
+	}
+	_, armRecords := run(BackendARM)
+	armRequests := len(srv.Requests())
+	reports, graphRecords := run(BackendResourceGraph)
+
+	assert.Equal(t, countBySubscription(armRecords), countBySubscription(graphRecords))
+	require.Len(t, reports, 2)
+	for _, report := range reports {
+		assert.Equal(t, 1, report.Kinds[KindCluster].Records)
+		assert.Equal(t, 1, report.Kinds[KindCluster].Pages)
+		assert.Equal(t, 1, report.Kinds[KindNodePool].Records)
+		assert.Equal(t, 1, report.Kinds[KindEncryptionFinding].Records)
+	}
+
+	var pages int
+	for _, r := range srv.Requests()[armRequests:] {
+		if r.Graph == nil {
+			assert.Contains(t, r.Path, "/agentPools", "only kinds without a query go through ARM")
+			continue
+		}
+		pages++
+		assert.Equal(t, subs, r.Graph.Subscriptions)
+		assert.Equal(t, 1000, r.Graph.Options.Top)
+	}
+	assert.Equal(t, 2+2+2+1+1, pages, "a page per row of groups, clusters and disks, an empty page of snapshots and disk encryption sets")
+}
+
+func TestMultiScrapper_ResourceGraph(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(t *testing.T, srv *armfake.Server)
+		options []OptionsFunc
+		expect  func(t *testing.T, srv *armfake.Server, reports []*RunReport, records []Record)
+	}{
+		{
+			name: "failing query fails its kind in every subscription",
+			setup: func(t *testing.T, srv *armfake.Server) {
+				srv.Fail(armfake.GraphPath, armfake.Failure{Status: http.StatusForbidden})
+			},
+			options: []OptionsFunc{WithKinds(KindCluster), WithPartialResults()},
+			expect: func(t *testing.T, srv *armfake.Server, reports []*RunReport, records []Record) {
+				require.Len(t, reports, 2)
+				for _, report := range reports {
+					assert.ErrorContains(t, report.Kinds[KindCluster].Err, "AuthorizationFailed")
+				}
+				assert.Empty(t, records)
+			},
+		},
+		{
+			name: "scoped scrape drops the rows of other resource groups",
+			options: []OptionsFunc{
+				WithKinds(KindCluster),
+				WithResourceGroupScope(ResourceGroupScope{Patterns: []string{"rg"}}),
+			},
+			expect: func(t *testing.T, srv *armfake.Server, reports []*RunReport, records []Record) {
+				var ids []string
+				for _, r := range records {
+					if c, ok := r.Payload.(*container.ManagedCluster); ok {
+						ids = append(ids, *c.ID)
+					}
+				}
+				assert.ElementsMatch(t, []string{armfake.ClusterID("sub-a", "rg", "aks"), armfake.ClusterID("sub-b", "rg", "aks")}, ids)
+			},
+		},
+		{
+			name:    "power state of virtual machines is read from the rows",
+			options: []OptionsFunc{WithKinds(KindVirtualMachine), WithInstanceView()},
+			expect: func(t *testing.T, srv *armfake.Server, reports []*RunReport, records []Record) {
+				require.Len(t, records, 1)
+				assert.Equal(t, "running", records[0].Payload.(*VirtualMachine).PowerState)
+				for _, r := range srv.Requests() {
+					assert.NotNil(t, r.Graph, "instance view of %s", r.Path)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := armfake.NewServer()
+			defer srv.Close()
+			var groups, resources []armfake.GraphRow
+			for _, sub := range []string{"sub-a", "sub-b"} {
+				for _, rg := range []string{"rg", "other"} {
+					groups = append(groups, armfake.GraphRow{SubscriptionID: sub, ResourceGroup: rg, Resource: resource.ResourceGroup{
+						Name: to(rg),
+						Type: to("microsoft.resources/subscriptions/resourcegroups"),
+					}})
+					c := cluster(sub, rg, "aks")
+					c.Type = to("microsoft.containerservice/managedclusters")
+					resources = append(resources, armfake.GraphRow{SubscriptionID: sub, ResourceGroup: rg, Resource: c})
+				}
+			}
+			resources = append(resources, armfake.GraphRow{SubscriptionID: "sub-a", ResourceGroup: "rg", Resource: map[string]any{
+				"id":   "/subscriptions/sub-a/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/vm",
+				"type": "microsoft.compute/virtualmachines",
+				"properties": map[string]any{
+					"extended": map[string]any{"instanceView": map[string]any{"powerState": map[string]any{"code": "PowerState/running"}}},
+				},
+			}})
+			require.NoError(t, srv.Graph("resourcecontainers", 0, groups...))
+			require.NoError(t, srv.Graph("resources", 0, resources...))
+			if tt.setup != nil {
+				tt.setup(t, srv)
+			}
+
+			sink := NewMemorySink()
+			m, err := NewMultiScrapper(armfake.Credential(), []string{"sub-a", "sub-b"},
+				append([]OptionsFunc{WithBackend(BackendResourceGraph), WithClientOptions(srv.ClientOptions()), WithSink(sink)}, tt.options...)...)
+			require.NoError(t, err)
+			reports, err := m.Run(context.Background())
+			require.NoError(t, err)
+			tt.expect(t, srv, reports, sink.Records())
+		})
+	}
+}
+
+func TestMultiScrapper_ResourceGraphGenericResources(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	vault := resource.GenericResourceExpanded{
+		ID:       to("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.KeyVault/vaults/kv"),
+		Name:     to("kv"),
+		Type:     to("Microsoft.KeyVault/vaults"),
+		Location: to("westeurope"),
+		SKU:      &resource.SKU{Name: to("standard")},
+		Tags:     map[string]*string{"team": to("payments")},
+	}
+	account := resource.GenericResourceExpanded{
+		ID:       to("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Storage/storageAccounts/st"),
+		Name:     to("st"),
+		Type:     to("Microsoft.Storage/storageAccounts"),
+		Kind:     to("StorageV2"),
+		Location: to("westeurope"),
+	}
+	// resource graph rows hold the properties of resources, lower cased types and empty columns
+	rows := []armfake.GraphRow{
+		{SubscriptionID: "sub", ResourceGroup: "rg", Resource: map[string]any{
+			"id": *vault.ID, "name": "kv", "type": "microsoft.keyvault/vaults", "location": "westeurope", "kind": "", "managedBy": "",
+			"sku": map[string]any{"name": "standard"}, "tags": map[string]any{"team": "payments"},
+			"properties": map[string]any{"tenantId": "tenant"},
+		}},
+		{SubscriptionID: "sub", ResourceGroup: "rg", Resource: map[string]any{
+			"id": *account.ID, "name": "st", "type": "microsoft.storage/storageaccounts", "location": "westeurope", "kind": "StorageV2",
+			"managedBy": "", "tags": map[string]any{}, "properties": map[string]any{"accessTier": "Hot"},
+		}},
+	}
+
+	tests := []struct {
+		name    string
+		arm     []any
+		options []OptionsFunc
+		expect  func(t *testing.T, requests []armfake.Request, arm []*resource.GenericResourceExpanded, graph []*resource.GenericResourceExpanded)
+	}{
+		{
+			name: "records match the ARM listing but for the lower cased types",
+			arm:  []any{vault, account},
+			expect: func(t *testing.T, requests []armfake.Request, arm []*resource.GenericResourceExpanded, graph []*resource.GenericResourceExpanded) {
+				require.Len(t, graph, 2)
+				for i := range graph {
+					assert.Equal(t, strings.ToLower(*arm[i].Type), *graph[i].Type)
+					graph[i].Type = arm[i].Type
+				}
+				assert.Equal(t, arm, graph)
+			},
+		},
+		{
+			name:    "timestamps are listed through ARM",
+			arm:     []any{resource.GenericResourceExpanded{ID: vault.ID, Name: vault.Name, Type: vault.Type, CreatedTime: &created, ChangedTime: &created}},
+			options: []OptionsFunc{WithResourceTimestamps()},
+			expect: func(t *testing.T, requests []armfake.Request, arm []*resource.GenericResourceExpanded, graph []*resource.GenericResourceExpanded) {
+				assert.Equal(t, arm, graph)
+				require.Len(t, graph, 1)
+				assert.Equal(t, created, *graph[0].CreatedTime)
+				for _, r := range requests {
+					assert.Nil(t, r.Graph, "resource graph does not hold timestamps")
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := armfake.NewServer()
+			defer srv.Close()
+			require.NoError(t, srv.List(armfake.ResourcesPath("sub"), 0, tt.arm...))
+			require.NoError(t, srv.Graph("resources", 0, rows...))
+
+			run := func(backend Backend) []*resource.GenericResourceExpanded {
+				sink := NewMemorySink()
+				m, err := NewMultiScrapper(armfake.Credential(), []string{"sub"}, append([]OptionsFunc{
+					WithKinds(KindGenericResource),
+					WithBackend(backend),
+					WithClientOptions(srv.ClientOptions()),
+					WithSink(sink),
+				}, tt.options...)...)
+				require.NoError(t, err)
+				_, err = m.Run(context.Background())
+				require.NoError(t, err)
+				var resources []*resource.GenericResourceExpanded
+				for _, r := range sink.Records() {
+					resources = append(resources, r.Payload.(*resource.GenericResourceExpanded))
+				}
+				sort.Slice(resources, func(i, j int) bool { return *resources[i].ID < *resources[j].ID })
+				return resources
+			}
+			arm := run(BackendARM)
+			armRequests := len(srv.Requests())
+			graph := run(BackendResourceGraph)
+			tt.expect(t, srv.Requests()[armRequests:], arm, graph)
+		})
+	}
+}
+
+func TestScrapper_ResourceGraphConcurrentRuns(t *testing.T) {
+	srv := armfake.NewServer()
+	defer srv.Close()
+	aks := cluster("sub", "rg", "aks")
+	aks.Type = to("microsoft.containerservice/managedclusters")
+	require.NoError(t, srv.Graph("resources", 0, armfake.GraphRow{SubscriptionID: "sub", ResourceGroup: "rg", Resource: aks}))
+
+	sink := NewMemorySink()
+	s, err := NewScrapper(armfake.Credential(), "sub",
+		WithKinds(KindCluster),
+		WithBackend(BackendResourceGraph),
+		WithClientOptions(srv.ClientOptions()),
+		WithSink(sink),
+	)
+	require.NoError(t, err)
+
+	var g errgroup.Group
+	for i := 0; i < 2; i++ {
+		g.Go(func() error {
+			_, err := s.Run(context.Background())
+			return err
+		})
+	}
+	require.NoError(t, g.Wait())
+	assert.Len(t, sink.Records(), 2)
+}
+
+// batchGraphClient serves a cluster row for every queried subscription, recording how many records were written before each query.
+type batchGraphClient struct {
+	sink    *MemorySink
+	batches []int
+	written []int
+}
+
+func (c *batchGraphClient) Resources(_ context.Context, query graph.QueryRequest, _ *graph.ClientResourcesOptions) (graph.ClientResourcesResponse, error) {
+	c.batches = append(c.batches, len(query.Subscriptions))
+	c.written = append(c.written, len(c.sink.Records()))
+	rows := make([]any, 0, len(query.Subscriptions))
+	for _, sub := range query.Subscriptions {
+		rows = append(rows, map[string]any{"id": armfake.ClusterID(*sub, "rg", "aks"), "subscriptionId": *sub, "resourceGroup": "rg"})
+	}
+	return graph.ClientResourcesResponse{QueryResponse: graph.QueryResponse{Data: rows}}, nil
+}
+
+func TestMultiScrapper_ResourceGraphBatches(t *testing.T) {
+	subs := make([]string, 1001)
+	for i := range subs {
+		subs[i] = fmt.Sprintf("sub-%04d", i)
+	}
+	sink := NewMemorySink()
+	client := &batchGraphClient{sink: sink}
+	var graphOptions *arm.ClientOptions
+	m, err := NewMultiScrapper(testCred(), subs,
+		WithKinds(KindCluster),
+		WithBackend(BackendResourceGraph),
+		WithResourceGraphFactory(func(_ az.TokenCredential, options *arm.ClientOptions) (ResourceGraphClient, error) {
+			graphOptions = options
+			return client, nil
+		}),
+		WithThrottling(DefaultThrottleOptions()),
+		WithSubscriptionConcurrency(4),
+		WithSink(sink),
+	)
+	require.NoError(t, err)
+	reports, err := m.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.Len(t, reports, 1001)
+	assert.Len(t, sink.Records(), 1001)
+	assert.Equal(t, []int{1000, 1}, client.batches)
+	assert.Equal(t, 1000, client.written[1], "the first batch is scraped before the next one is queried")
+	require.NotNil(t, graphOptions)
+	assert.Len(t, graphOptions.PerRetryPolicies, 1, "resource graph queries are throttled")
+}
+
+func countBySubscription(records []Record) map[string]int {
+	counts := map[string]int{}
+	for _, r := range records {
+		counts[strings.Join([]string{r.SubscriptionID, string(r.Kind)}, "/")]++
+	}
+	return counts
+}
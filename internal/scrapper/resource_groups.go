@@ -2,34 +2,56 @@ package scrapper
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
 	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
 )
 
+const KindResourceGroup Kind = "resourceGroup"
+
 // ResourceGroupsPager used to scrape resource group information
 type ResourceGroupsPager interface {
 	NewListPager(options *resource.ResourceGroupsClientListOptions) *rt.Pager[resource.ResourceGroupsClientListResponse]
 }
 
-type ResourceGroupClientFactory func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (ResourceGroupsPager, error)
+type ResourceGroupClientFactory = ClientFactory[ResourceGroupsPager]
 
-func defaultResourceGroupFactory(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (ResourceGroupsPager, error) {
-	return resource.NewResourceGroupsClient(subscriptionID, credential, options)
+func init() {
+	Register(Collector[ResourceGroupsPager]{
+		Kind: KindResourceGroup,
+		Factory: func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (ResourceGroupsPager, error) {
+			return resource.NewResourceGroupsClient(subscriptionID, credential, options)
+		},
+		Collect: func(ctx context.Context, c *Collection, _ ResourceGroupsPager) error {
+			return c.Scrapper().ListResourceGroups(ctx, resourceGroupHandler(c))
+		},
+		Graph: &GraphQuery{
+			Query: "resourcecontainers | where type =~ 'microsoft.resources/subscriptions/resourcegroups'",
+			Collect: func(ctx context.Context, c *Collection, rows []json.RawMessage) error {
+				return graphRows(ctx, rows, resourceGroupHandler(c))
+			},
+		},
+	})
 }
 
-func (s *Scrapper) ListResourceGroups(ctx context.Context, pageHandler pageHandler[resource.ResourceGroup]) error {
-	pager := s.resourceGroupClient.NewListPager(nil)
-	for pager.More() {
-		page, err := pager.NextPage(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to advance page: %w", err)
-		}
-		if err = processPage(page.Value, err, pageHandler); err != nil {
-			return err
+// resourceGroupHandler emits the resource groups in the scope of the scrape.
+func resourceGroupHandler(c *Collection) pageHandler[resource.ResourceGroup] {
+	emit := emitHandler[resource.ResourceGroup](c, KindResourceGroup)
+	return func(rg *resource.ResourceGroup) error {
+		if !c.inScope(rg) {
+			return nil
 		}
+		return emit(rg)
 	}
-	return nil
+}
+
+func WithResourceGroupsFactory(f ResourceGroupClientFactory) OptionsFunc {
+	return WithFactory(KindResourceGroup, f)
+}
+
+func (s *Scrapper) ListResourceGroups(ctx context.Context, pageHandler pageHandler[resource.ResourceGroup]) error {
+	pager := clientFor[ResourceGroupsPager](s, KindResourceGroup).NewListPager(nil)
+	return listPages(ctx, pager, func(p resource.ResourceGroupsClientListResponse) []*resource.ResourceGroup { return p.Value }, pageHandler)
 }
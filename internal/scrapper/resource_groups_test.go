@@ -2,11 +2,10 @@ package scrapper_test
 
 import (
 	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
 	"context"
 	"errors"
 
-	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,37 +20,23 @@ func TestScrapper_ListResourceGroups(t *testing.T) {
 		want                  func(t *testing.T, err error)
 	}{
 		{
-			name: "resource group iteration succeeds",
-			resourceGroupsFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (ResourceGroupsPager, error) {
-				return NewPager[resource.ResourceGroupsClientListOptions, resource.ResourceGroupsClientListResponse]{item: &resource.ResourceGroupsClientListResponse{
-					ResourceGroupListResult: resource.ResourceGroupListResult{
-						Value: []*resource.ResourceGroup{{}},
-					},
-				}}, nil
-			},
+			name:                  "resource group iteration succeeds",
+			resourceGroupsFactory: scrappertest.Factory[ResourceGroupsPager](scrappertest.NewResourceGroupsPager(scrappertest.Items(&resource.ResourceGroup{}))),
 			want: func(t *testing.T, err error) {
 				assert.NoError(t, err)
 			},
 		},
 		{
-			name: "resource group handler fails",
-			resourceGroupsFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (ResourceGroupsPager, error) {
-				return NewPager[resource.ResourceGroupsClientListOptions, resource.ResourceGroupsClientListResponse]{item: &resource.ResourceGroupsClientListResponse{
-					ResourceGroupListResult: resource.ResourceGroupListResult{
-						Value: []*resource.ResourceGroup{{}},
-					},
-				}}, nil
-			},
-			handlerError: errors.New("failed to Handle resource group"),
+			name:                  "resource group handler fails",
+			resourceGroupsFactory: scrappertest.Factory[ResourceGroupsPager](scrappertest.NewResourceGroupsPager(scrappertest.Items(&resource.ResourceGroup{}))),
+			handlerError:          errors.New("failed to Handle resource group"),
 			want: func(t *testing.T, err error) {
 				assert.Error(t, err)
 			},
 		},
 		{
-			name: "resource group iteration fails",
-			resourceGroupsFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (ResourceGroupsPager, error) {
-				return FailPager[resource.ResourceGroupsClientListOptions, resource.ResourceGroupsClientListResponse]{}, nil
-			},
+			name:                  "resource group iteration fails",
+			resourceGroupsFactory: scrappertest.Factory[ResourceGroupsPager](scrappertest.NewResourceGroupsPager(scrappertest.Fail[resource.ResourceGroup](errors.New("failed to iterate")))),
 			want: func(t *testing.T, err error) {
 				assert.Error(t, err)
 			},
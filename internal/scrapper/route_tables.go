@@ -0,0 +1,55 @@
+package scrapper
+
+import (
+	"context"
+	"encoding/json"
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	network "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v2"
+)
+
+const KindRouteTable Kind = "routeTable"
+
+// RouteTablePager used to scrape route table information
+type RouteTablePager interface {
+	NewListAllPager(options *network.RouteTablesClientListAllOptions) *rt.Pager[network.RouteTablesClientListAllResponse]
+	NewListPager(resourceGroupName string, options *network.RouteTablesClientListOptions) *rt.Pager[network.RouteTablesClientListResponse]
+}
+
+type RouteTableClientFactory = ClientFactory[RouteTablePager]
+
+func init() {
+	Register(Collector[RouteTablePager]{
+		Kind: KindRouteTable,
+		Factory: func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (RouteTablePager, error) {
+			return network.NewRouteTablesClient(subscriptionID, credential, options)
+		},
+		Collect: func(ctx context.Context, c *Collection, _ RouteTablePager) error {
+			return c.Scrapper().ListRouteTables(ctx, emitHandler[network.RouteTable](c, KindRouteTable))
+		},
+		ListByResourceGroup: func(ctx context.Context, c *Collection, _ RouteTablePager, resourceGroup string) error {
+			return c.Scrapper().ListRouteTablesByResourceGroup(ctx, resourceGroup, emitHandler[network.RouteTable](c, KindRouteTable))
+		},
+		Graph: &GraphQuery{
+			Query: graphResources("microsoft.network/routetables"),
+			Collect: func(ctx context.Context, c *Collection, rows []json.RawMessage) error {
+				return graphRows(ctx, rows, emitHandler[network.RouteTable](c, KindRouteTable))
+			},
+		},
+	})
+}
+
+func WithRouteTableFactory(f RouteTableClientFactory) OptionsFunc {
+	return WithFactory(KindRouteTable, f)
+}
+
+func (s *Scrapper) ListRouteTables(ctx context.Context, pageHandler pageHandler[network.RouteTable]) error {
+	pager := clientFor[RouteTablePager](s, KindRouteTable).NewListAllPager(nil)
+	return listPages(ctx, pager, func(p network.RouteTablesClientListAllResponse) []*network.RouteTable { return p.Value }, pageHandler)
+}
+
+func (s *Scrapper) ListRouteTablesByResourceGroup(ctx context.Context, resourceGroup string, pageHandler pageHandler[network.RouteTable]) error {
+	pager := clientFor[RouteTablePager](s, KindRouteTable).NewListPager(resourceGroup, nil)
+	return listPages(ctx, pager, func(p network.RouteTablesClientListResponse) []*network.RouteTable { return p.Value }, pageHandler)
+}
@@ -0,0 +1,170 @@
+package scrapper
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	KindScaleSet         Kind = "virtualMachineScaleSet"
+	KindScaleSetInstance Kind = "scaleSetInstance"
+)
+
+// tagAgentPool is the tag AKS sets on the scale sets of its node resource group with the name of their agent pool.
+const tagAgentPool = "aks-managed-poolName"
+
+// ScaleSetPager used to scrape virtual machine scale set information
+type ScaleSetPager interface {
+	NewListAllPager(options *compute.VirtualMachineScaleSetsClientListAllOptions) *rt.Pager[compute.VirtualMachineScaleSetsClientListAllResponse]
+	NewListPager(resourceGroupName string, options *compute.VirtualMachineScaleSetsClientListOptions) *rt.Pager[compute.VirtualMachineScaleSetsClientListResponse]
+}
+
+// ScaleSetInstancePager used to scrape the instances of virtual machine scale sets
+type ScaleSetInstancePager interface {
+	NewListPager(resourceGroupName string, virtualMachineScaleSetName string, options *compute.VirtualMachineScaleSetVMsClientListOptions) *rt.Pager[compute.VirtualMachineScaleSetVMsClientListResponse]
+}
+
+type ScaleSetClientFactory = ClientFactory[ScaleSetPager]
+type ScaleSetInstanceClientFactory = ClientFactory[ScaleSetInstancePager]
+
+func init() {
+	Register(Collector[ScaleSetPager]{
+		Kind:      KindScaleSet,
+		DependsOn: []Kind{KindCluster},
+		Factory: func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (ScaleSetPager, error) {
+			return compute.NewVirtualMachineScaleSetsClient(subscriptionID, credential, options)
+		},
+		Collect: func(ctx context.Context, c *Collection, _ ScaleSetPager) error {
+			return c.Scrapper().ListScaleSets(ctx, scaleSetHandler(c))
+		},
+		ListByResourceGroup: func(ctx context.Context, c *Collection, _ ScaleSetPager, resourceGroup string) error {
+			return c.Scrapper().ListScaleSetsByResourceGroup(ctx, resourceGroup, scaleSetHandler(c))
+		},
+		Graph: &GraphQuery{
+			Query: graphResources("microsoft.compute/virtualmachinescalesets"),
+			Collect: func(ctx context.Context, c *Collection, rows []json.RawMessage) error {
+				return graphRows(ctx, rows, scaleSetHandler(c))
+			},
+		},
+	})
+	Register(Collector[ScaleSetInstancePager]{
+		Kind:      KindScaleSetInstance,
+		DependsOn: []Kind{KindScaleSet},
+		Optional:  true,
+		Factory: func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (ScaleSetInstancePager, error) {
+			return compute.NewVirtualMachineScaleSetVMsClient(subscriptionID, credential, options)
+		},
+		Collect: func(ctx context.Context, c *Collection, _ ScaleSetInstancePager) error {
+			scaleSets := Collected[ScaleSet](c, KindScaleSet)
+			return c.Scrapper().ListScaleSetInstances(ctx, scaleSets, emitHandler[ScaleSetInstance](c, KindScaleSetInstance))
+		},
+	})
+}
+
+func WithScaleSetFactory(f ScaleSetClientFactory) OptionsFunc {
+	return WithFactory(KindScaleSet, f)
+}
+
+func WithScaleSetInstanceFactory(f ScaleSetInstanceClientFactory) OptionsFunc {
+	return WithFactory(KindScaleSetInstance, f)
+}
+
+// ScaleSet is a virtual machine scale set, linked to the managed cluster and agent pool it runs the nodes of
+// when it belongs to the node resource group of a collected cluster.
+type ScaleSet struct {
+	ClusterID   string                          `json:"clusterId,omitempty"`
+	AgentPoolID string                          `json:"agentPoolId,omitempty"`
+	ScaleSet    *compute.VirtualMachineScaleSet `json:"scaleSet"`
+}
+
+// ScaleSetInstance is a virtual machine of a scale set, its power state is only read when WithInstanceView is set.
+type ScaleSetInstance struct {
+	ScaleSetID     string                            `json:"scaleSetId"`
+	PowerState     string                            `json:"powerState,omitempty"`
+	VirtualMachine *compute.VirtualMachineScaleSetVM `json:"virtualMachine"`
+}
+
+func (s *Scrapper) ListScaleSets(ctx context.Context, pageHandler pageHandler[compute.VirtualMachineScaleSet]) error {
+	pager := clientFor[ScaleSetPager](s, KindScaleSet).NewListAllPager(nil)
+	return listPages(ctx, pager, func(p compute.VirtualMachineScaleSetsClientListAllResponse) []*compute.VirtualMachineScaleSet {
+		return p.Value
+	}, pageHandler)
+}
+
+func (s *Scrapper) ListScaleSetsByResourceGroup(ctx context.Context, resourceGroup string, pageHandler pageHandler[compute.VirtualMachineScaleSet]) error {
+	pager := clientFor[ScaleSetPager](s, KindScaleSet).NewListPager(resourceGroup, nil)
+	return listPages(ctx, pager, func(p compute.VirtualMachineScaleSetsClientListResponse) []*compute.VirtualMachineScaleSet {
+		return p.Value
+	}, pageHandler)
+}
+
+// scaleSetHandler emits scale sets linked to the collected clusters whose node resource group they belong to.
+func scaleSetHandler(c *Collection) pageHandler[compute.VirtualMachineScaleSet] {
+	clusters := map[string]string{}
+	for _, cluster := range Collected[container.ManagedCluster](c, KindCluster) {
+		if cluster.ID != nil && cluster.Properties != nil && cluster.Properties.NodeResourceGroup != nil {
+			clusters[strings.ToLower(*cluster.Properties.NodeResourceGroup)] = *cluster.ID
+		}
+	}
+	emit := emitHandler[ScaleSet](c, KindScaleSet)
+	return func(vmss *compute.VirtualMachineScaleSet) error {
+		scaleSet := &ScaleSet{ScaleSet: vmss}
+		if vmss.ID != nil {
+			if id, err := arm.ParseResourceID(*vmss.ID); err == nil {
+				scaleSet.ClusterID = clusters[strings.ToLower(id.ResourceGroupName)]
+			}
+		}
+		if pool := vmss.Tags[tagAgentPool]; scaleSet.ClusterID != "" && pool != nil {
+			scaleSet.AgentPoolID = scaleSet.ClusterID + "/agentPools/" + *pool
+		}
+		return emit(scaleSet)
+	}
+}
+
+func (s *Scrapper) ListScaleSetInstance(ctx context.Context, rg string, name string, pageHandler pageHandler[compute.VirtualMachineScaleSetVM]) error {
+	var options *compute.VirtualMachineScaleSetVMsClientListOptions
+	if s.kindConfig(KindScaleSetInstance).expand {
+		expand := "instanceView"
+		options = &compute.VirtualMachineScaleSetVMsClientListOptions{Expand: &expand}
+	}
+	pager := clientFor[ScaleSetInstancePager](s, KindScaleSetInstance).NewListPager(rg, name, options)
+	return listPages(ctx, pager, func(p compute.VirtualMachineScaleSetVMsClientListResponse) []*compute.VirtualMachineScaleSetVM {
+		return p.Value
+	}, pageHandler)
+}
+
+// ListScaleSetInstances lists the instances of every scale set, using at most the configured concurrency of workers.
+func (s *Scrapper) ListScaleSetInstances(ctx context.Context, scaleSets []*ScaleSet, pageHandler pageHandler[ScaleSetInstance]) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.kindConfig(KindScaleSetInstance).concurrency)
+	for _, scaleSet := range scaleSets {
+		if scaleSet.ScaleSet == nil || scaleSet.ScaleSet.ID == nil {
+			continue
+		}
+		id, err := arm.ParseResourceID(*scaleSet.ScaleSet.ID)
+		if err != nil {
+			g.Go(func() error { return fmt.Errorf("failed to parse scale set id: %w", err) })
+			break
+		}
+		scaleSetID := *scaleSet.ScaleSet.ID
+		g.Go(func() error {
+			return s.ListScaleSetInstance(ctx, id.ResourceGroupName, id.Name, func(vm *compute.VirtualMachineScaleSetVM) error {
+				instance := &ScaleSetInstance{ScaleSetID: scaleSetID, VirtualMachine: vm}
+				if vm.Properties != nil && vm.Properties.InstanceView != nil {
+					instance.PowerState = PowerState(vm.Properties.InstanceView.Statuses)
+				}
+				return pageHandler(instance)
+			})
+		})
+	}
+	return g.Wait()
+}
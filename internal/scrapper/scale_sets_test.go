@@ -0,0 +1,83 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
+	"context"
+	"testing"
+
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapper_ScaleSets(t *testing.T) {
+	scaleSetID := func(rg string, name string) string {
+		return "/subscriptions/sub/resourceGroups/" + rg + "/providers/Microsoft.Compute/virtualMachineScaleSets/" + name
+	}
+	clusters := scrappertest.NewClusterPager(scrappertest.Items(&container.ManagedCluster{
+		ID:         to(armClusterID("aks")),
+		Properties: &container.ManagedClusterProperties{NodeResourceGroup: to("MC_rg_aks_westeurope")},
+	}))
+	scaleSets := scrappertest.NewScaleSetPager(scrappertest.Items(
+		&compute.VirtualMachineScaleSet{
+			ID:   to(scaleSetID("mc_rg_aks_westeurope", "aks-system-12345678-vmss")),
+			Tags: map[string]*string{"aks-managed-poolName": to("system")},
+		},
+		&compute.VirtualMachineScaleSet{
+			ID:   to(scaleSetID("rg-batch", "batch-vmss")),
+			Tags: map[string]*string{"aks-managed-poolName": to("system")},
+		},
+	))
+	instances := scrappertest.NewScaleSetInstancePager().
+		ScaleSet("mc_rg_aks_westeurope", "aks-system-12345678-vmss", scrappertest.Items(&compute.VirtualMachineScaleSetVM{
+			InstanceID: to("0"),
+			Properties: &compute.VirtualMachineScaleSetVMProperties{InstanceView: &compute.VirtualMachineScaleSetVMInstanceView{
+				Statuses: []*compute.InstanceViewStatus{{Code: to("PowerState/stopped")}},
+			}},
+		})).
+		ScaleSet("rg-batch", "batch-vmss", scrappertest.Items(&compute.VirtualMachineScaleSetVM{InstanceID: to("0")}))
+
+	sink := NewMemorySink()
+	s, err := NewScrapper(testCred(), "sub",
+		WithKinds(KindScaleSet, KindScaleSetInstance),
+		WithInstanceView(),
+		WithClusterFactory(scrappertest.Factory[ClusterPager](clusters)),
+		WithScaleSetFactory(scrappertest.Factory[ScaleSetPager](scaleSets)),
+		WithScaleSetInstanceFactory(scrappertest.Factory[ScaleSetInstancePager](instances)),
+		WithSink(sink),
+	)
+	require.NoError(t, err)
+	_, err = s.Run(context.Background())
+	require.NoError(t, err)
+
+	linked := map[string]*ScaleSet{}
+	powerStates := map[string]string{}
+	for _, r := range sink.Records() {
+		switch p := r.Payload.(type) {
+		case *ScaleSet:
+			linked[*p.ScaleSet.ID] = p
+		case *ScaleSetInstance:
+			powerStates[p.ScaleSetID] = p.PowerState
+		default:
+			t.Fatalf("unexpected %s record", r.Kind)
+		}
+	}
+	require.Len(t, linked, 2)
+	aks := linked[scaleSetID("mc_rg_aks_westeurope", "aks-system-12345678-vmss")]
+	assert.Equal(t, armClusterID("aks"), aks.ClusterID)
+	assert.Equal(t, armClusterID("aks")+"/agentPools/system", aks.AgentPoolID)
+	batch := linked[scaleSetID("rg-batch", "batch-vmss")]
+	assert.Empty(t, batch.ClusterID)
+	assert.Empty(t, batch.AgentPoolID)
+
+	assert.Equal(t, map[string]string{
+		scaleSetID("mc_rg_aks_westeurope", "aks-system-12345678-vmss"): "stopped",
+		scaleSetID("rg-batch", "batch-vmss"):                           "",
+	}, powerStates)
+	for _, call := range instances.Calls() {
+		require.NotNil(t, call.Options)
+		assert.Equal(t, "instanceView", *call.Options.Expand)
+	}
+}
@@ -0,0 +1,132 @@
+package scrapper
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+)
+
+// ResourceGroupScope restricts a scrape to the resource groups it matches, so it can run with resource group scoped RBAC.
+// A group matches when its name is one of Names or matches one of the Patterns, any name matches when both are empty,
+// and when it carries every tag of Tags, a tag with an empty value matches any value.
+type ResourceGroupScope struct {
+	Names    []string
+	Patterns []string
+	Tags     map[string]string
+}
+
+// ParseResourceGroupScope reads a scope from a comma separated list of resource group names and globs such as
+// rg-payments,team-*, and a comma separated tag selector such as team=payments,env.
+func ParseResourceGroupScope(groups string, tags string) (ResourceGroupScope, error) {
+	var scope ResourceGroupScope
+	for _, g := range splitList(groups) {
+		if strings.ContainsAny(g, "*?[") {
+			scope.Patterns = append(scope.Patterns, g)
+		} else {
+			scope.Names = append(scope.Names, g)
+		}
+	}
+	for _, tag := range splitList(tags) {
+		if scope.Tags == nil {
+			scope.Tags = map[string]string{}
+		}
+		k, v, _ := strings.Cut(tag, "=")
+		scope.Tags[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return scope, scope.validate()
+}
+
+func (s ResourceGroupScope) validate() error {
+	for _, p := range s.Patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("invalid resource group pattern %q: %w", p, err)
+		}
+	}
+	for k := range s.Tags {
+		if k == "" {
+			return fmt.Errorf("invalid resource group tag selector: empty tag name")
+		}
+	}
+	return nil
+}
+
+// scopedExclusions adds the subscription level kinds to exclude, they have no resource group listing and are found
+// from no other kind. Requesting one of them explicitly fails, since a scoped scrape cannot list it.
+func scopedExclusions(include []Kind, exclude []Kind) ([]Kind, error) {
+	for _, kind := range include {
+		if c, ok := registered(kind); ok && subscriptionLevel(c) {
+			return nil, fmt.Errorf("resource kind %s is subscription level and cannot be scraped in a resource group scope", kind)
+		}
+	}
+	exclude = append([]Kind{}, exclude...)
+	for _, kind := range Kinds() {
+		if c, ok := registered(kind); ok && subscriptionLevel(c) {
+			exclude = append(exclude, kind)
+		}
+	}
+	return exclude, nil
+}
+
+func subscriptionLevel(c registration) bool {
+	return c.kind() != KindResourceGroup && !c.scopable() && len(c.dependsOn()) == 0
+}
+
+// Matches reports whether the resource group is in scope, names and tag names are compared case-insensitively like ARM does.
+func (s ResourceGroupScope) Matches(rg *resource.ResourceGroup) bool {
+	if rg == nil || rg.Name == nil {
+		return false
+	}
+	return s.matchesName(*rg.Name) && s.matchesTags(rg.Tags)
+}
+
+func (s ResourceGroupScope) matchesName(name string) bool {
+	if len(s.Names) == 0 && len(s.Patterns) == 0 {
+		return true
+	}
+	name = strings.ToLower(name)
+	for _, n := range s.Names {
+		if strings.ToLower(n) == name {
+			return true
+		}
+	}
+	for _, p := range s.Patterns {
+		if ok, _ := path.Match(strings.ToLower(p), name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (s ResourceGroupScope) matchesTags(tags map[string]*string) bool {
+	for k, want := range s.Tags {
+		got, ok := tagValue(tags, k)
+		if !ok || (want != "" && got != want) {
+			return false
+		}
+	}
+	return true
+}
+
+func tagValue(tags map[string]*string, key string) (string, bool) {
+	for k, v := range tags {
+		if strings.EqualFold(k, key) {
+			if v == nil {
+				return "", true
+			}
+			return *v, true
+		}
+	}
+	return "", false
+}
+
+// WithResourceGroupScope restricts the scrape to the resource groups matched by scope. The resource groups visible to
+// the credential are listed first, then every kind lists the matched groups one by one. Kinds found from other kinds,
+// such as node pools, follow the scoped resources. Subscription level kinds, such as providers, are left out of the
+// scrape, NewScrapper fails when one of them is selected with WithKinds.
+func WithResourceGroupScope(scope ResourceGroupScope) OptionsFunc {
+	return func(opt *Options) {
+		opt.scope = &scope
+	}
+}
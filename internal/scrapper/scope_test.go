@@ -0,0 +1,149 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
+	"context"
+	"testing"
+
+	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	network "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v2"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResourceGroupScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		groups  string
+		tags    string
+		want    ResourceGroupScope
+		wantErr string
+	}{
+		{
+			name:   "names and patterns",
+			groups: "rg-payments, team-*,rg-?",
+			want:   ResourceGroupScope{Names: []string{"rg-payments"}, Patterns: []string{"team-*", "rg-?"}},
+		},
+		{
+			name: "tag selector",
+			tags: "team=payments, env",
+			want: ResourceGroupScope{Tags: map[string]string{"team": "payments", "env": ""}},
+		},
+		{
+			name:    "malformed pattern",
+			groups:  "rg-[a",
+			wantErr: `invalid resource group pattern "rg-[a"`,
+		},
+		{
+			name:    "empty tag name",
+			tags:    "=payments",
+			wantErr: "empty tag name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseResourceGroupScope(tt.groups, tt.tags)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResourceGroupScope_Matches(t *testing.T) {
+	rg := &resource.ResourceGroup{
+		Name: to("RG-Payments-West"),
+		Tags: map[string]*string{"Team": to("payments"), "env": to("prod")},
+	}
+	tests := []struct {
+		name  string
+		scope ResourceGroupScope
+		want  bool
+	}{
+		{name: "empty scope", scope: ResourceGroupScope{}, want: true},
+		{name: "name ignores case", scope: ResourceGroupScope{Names: []string{"rg-payments-west"}}, want: true},
+		{name: "other name", scope: ResourceGroupScope{Names: []string{"rg-payments"}}, want: false},
+		{name: "pattern", scope: ResourceGroupScope{Patterns: []string{"rg-payments-*"}}, want: true},
+		{name: "pattern or name", scope: ResourceGroupScope{Names: []string{"rg-x"}, Patterns: []string{"*-west"}}, want: true},
+		{name: "tag value", scope: ResourceGroupScope{Tags: map[string]string{"team": "payments"}}, want: true},
+		{name: "tag any value", scope: ResourceGroupScope{Tags: map[string]string{"env": ""}}, want: true},
+		{name: "other tag value", scope: ResourceGroupScope{Tags: map[string]string{"env": "dev"}}, want: false},
+		{name: "missing tag", scope: ResourceGroupScope{Tags: map[string]string{"owner": ""}}, want: false},
+		{
+			name:  "name and tags",
+			scope: ResourceGroupScope{Patterns: []string{"rg-*"}, Tags: map[string]string{"env": "dev"}},
+			want:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.scope.Matches(rg))
+		})
+	}
+}
+
+func TestScrapper_ResourceGroupScope(t *testing.T) {
+	clusterIn := func(rg string, name string) *container.ManagedCluster {
+		return &container.ManagedCluster{
+			ID:   to("/subscriptions/sub/resourceGroups/" + rg + "/providers/Microsoft.ContainerService/managedClusters/" + name),
+			Name: to(name),
+		}
+	}
+	groups := scrappertest.NewResourceGroupsPager(scrappertest.Items(
+		&resource.ResourceGroup{Name: to("rg-a"), Tags: map[string]*string{"team": to("payments")}},
+		&resource.ResourceGroup{Name: to("rg-b"), Tags: map[string]*string{"team": to("search")}},
+		&resource.ResourceGroup{Name: to("rg-c"), Tags: map[string]*string{"team": to("payments")}},
+	))
+	clusters := scrappertest.NewClusterPager(scrappertest.Items(clusterIn("rg-b", "aks-all"))).
+		ResourceGroup("rg-a", scrappertest.Items(clusterIn("rg-a", "aks-a"))).
+		ResourceGroup("rg-b", scrappertest.Items(clusterIn("rg-b", "aks-b")))
+	nodePools := scrappertest.NewNodePoolPager(scrappertest.Items(&container.AgentPool{Name: to("system")}))
+	vnets := scrappertest.NewVirtualNetworkPager().
+		ResourceGroup("rg-c", scrappertest.Items(&network.VirtualNetwork{Name: to("vnet-c")}))
+	providers := scrappertest.NewProvidersPager(scrappertest.Items(&resource.Provider{Namespace: to("Microsoft.Compute")}))
+
+	scope, err := ParseResourceGroupScope("rg-*", "team=payments")
+	require.NoError(t, err)
+	sink := NewMemorySink()
+	s, err := NewScrapper(testCred(), "sub", append(scrappertest.Empty(),
+		WithResourceGroupsFactory(scrappertest.Factory[ResourceGroupsPager](groups)),
+		WithClusterFactory(scrappertest.Factory[ClusterPager](clusters)),
+		WithNodePoolFactory(scrappertest.Factory[NodePoolPager](nodePools)),
+		WithVirtualNetworksFactory(scrappertest.Factory[VirtualNetworkPager](vnets)),
+		WithProvidersFactory(scrappertest.Factory[ProvidersPager](providers)),
+		WithResourceGroupScope(scope),
+		WithSink(sink),
+	)...)
+	require.NoError(t, err)
+
+	report, err := s.Run(context.Background())
+	require.NoError(t, err)
+
+	assert.NotContains(t, report.Kinds, KindProvider, "providers are subscription level")
+	assert.Equal(t, map[Kind]int{KindResourceGroup: 2, KindCluster: 1, KindNodePool: 1, KindVirtualNetwork: 1}, countKinds(sink.Records()))
+	assert.Empty(t, clusters.Calls(), "subscription wide listing")
+	assert.Empty(t, providers.Calls())
+	var listed []string
+	for _, call := range clusters.GroupCalls() {
+		listed = append(listed, call.ResourceGroup)
+	}
+	assert.ElementsMatch(t, []string{"rg-a", "rg-c"}, listed)
+	require.Len(t, nodePools.Calls(), 1)
+	assert.Equal(t, "rg-a", nodePools.Calls()[0].ResourceGroup)
+}
+
+func TestNewScrapper_ResourceGroupScopeSubscriptionLevelKind(t *testing.T) {
+	scope, err := ParseResourceGroupScope("rg-a", "")
+	require.NoError(t, err)
+
+	_, err = NewScrapper(testCred(), "sub", append(scrappertest.Empty(),
+		WithKinds(KindCluster, KindProvider),
+		WithResourceGroupScope(scope),
+	)...)
+	assert.EqualError(t, err, "resource kind provider is subscription level and cannot be scraped in a resource group scope")
+}
@@ -2,112 +2,242 @@ package scrapper
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"strings"
 	"time"
 
 	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
-	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
-	network "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v2"
-	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/google/uuid"
 	"golang.org/x/sync/errgroup"
 )
 
 type pageHandler[T any] func(r *T) error
 
 type Scrapper struct {
-	resourceGroupClient     ResourceGroupsPager
-	providersClient         ProvidersPager
-	networksClient          VirtualNetworkPager
-	diskEncryptionSetClient DiskEncryptionSetPager
-	clusterClient           ClusterPager
-	nodePoolClient          NodePoolPager
+	subscriptionID string
+	collectors     []registration
+	clients        map[Kind]any
+	kindConfigs    map[Kind]kindConfig
+	sink           Sink
+	timeout        time.Duration
+	kindTimeouts   map[Kind]time.Duration
+	partial        bool
+	emitted        map[Kind]bool
+	scope          *ResourceGroupScope
+	graphClient    ResourceGraphClient
+	graphKinds     []Kind
+	graph          graphResults
 }
 
 // NewScrapper initialize the scrapper using the provided credentials for a single subscription.
-// By default, the scrapper is initialized with the clients provided by the azure-go-sdk.
+// By default, the scrapper is initialized with the clients provided by the azure-go-sdk for every registered collector.
 // clients can be overwritten by passing in option functions.
 func NewScrapper(cred az.TokenCredential, sub string, opts ...OptionsFunc) (*Scrapper, error) {
-	o := DefaultOptions()
-	for _, fn := range opts {
-		fn(o)
-	}
+	o := resolveOptions(opts...)
+	o.useThrottle()
 
-	rgc, err := o.resourceGroupClientFactory(sub, cred, nil)
+	emitted, collected, err := o.selectKinds()
 	if err != nil {
 		return nil, err
 	}
 
-	pc, err := o.providersClientFactory(sub, cred, nil)
-	if err != nil {
-		return nil, err
+	s := &Scrapper{
+		subscriptionID: sub,
+		clients:        map[Kind]any{},
+		kindConfigs:    o.kindConfigs,
+		sink:           o.sink,
+		timeout:        o.timeout,
+		kindTimeouts:   o.kindTimeouts,
+		partial:        o.partial,
+		emitted:        emitted,
+		scope:          o.scope,
+		graph:          o.graphResults,
+	}
+	graphKinds := map[Kind]bool{}
+	for _, kind := range o.graphKinds(collected) {
+		graphKinds[kind] = true
+		s.graphKinds = append(s.graphKinds, kind)
+	}
+	if len(s.graphKinds) > 0 && s.graph == nil {
+		if s.graphClient, err = o.graphClientFactory(cred, o.baseClientOptions()); err != nil {
+			return nil, err
+		}
 	}
 
-	nc, err := o.virtualNetworkClientFactory(sub, cred, nil)
-	if err != nil {
-		return nil, err
+	for _, kind := range collected {
+		c, _ := registered(kind)
+		if graphKinds[kind] {
+			s.collectors = append(s.collectors, c)
+			continue
+		}
+		client, err := c.newClient(o, sub, cred)
+		if err != nil {
+			return nil, err
+		}
+		s.collectors = append(s.collectors, c)
+		s.clients[kind] = client
 	}
+	return s, nil
+}
 
-	desc, err := o.diskEncryptionSetClientFactory(sub, cred, nil)
-	if err != nil {
-		return nil, err
+// Run scrapes every resource kind and writes the records to the configured sink.
+// Collectors run concurrently, each one starting once the kinds it depends on are collected.
+// The scrape is bounded by the caller context, the configured overall timeout and the per kind timeouts.
+// By default the first failing kind cancels the rest of the scrape, with partial results enabled every kind
+// runs to completion and failures are only recorded in the returned report.
+func (s *Scrapper) Run(ctx context.Context) (report *RunReport, err error) {
+	kinds := make([]Kind, 0, len(s.collectors))
+	for _, c := range s.collectors {
+		kinds = append(kinds, c.kind())
+	}
+	report = newRunReport(s.subscriptionID, uuid.NewString(), kinds...)
+	defer func() {
+		report.Duration = time.Since(report.StartedAt)
+	}()
+
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
 	}
 
-	cc, err := o.clusterClientFactory(sub, cred, nil)
-	if err != nil {
-		return nil, err
+	if err = s.sink.Open(ctx); err != nil {
+		return report, fmt.Errorf("failed to open sink: %w", err)
 	}
+	defer func() {
+		err = errors.Join(err, s.sink.Flush(), s.sink.Close())
+	}()
 
-	npc, err := o.nodePoolClientFactory(sub, cred, nil)
+	col := s.newCollection(report)
+	if s.graphClient != nil {
+		col.graph = queryGraph(ctx, s.graphClient, s.graphKinds, []string{s.subscriptionID})[strings.ToLower(s.subscriptionID)]
+	}
+	done := make(map[Kind]chan struct{}, len(s.collectors))
+	for _, c := range s.collectors {
+		done[c.kind()] = make(chan struct{})
+	}
+
+	g := &errgroup.Group{}
+	if !s.partial {
+		g, ctx = errgroup.WithContext(ctx)
+	}
+	for _, c := range s.collectors {
+		c := c
+		g.Go(func() error {
+			defer close(done[c.kind()])
+			for _, dep := range s.dependsOn(c) {
+				<-done[dep]
+			}
+			return s.scrapeKind(ctx, report, c.kind(), func(ctx context.Context) error {
+				return s.collect(ctx, col, c)
+			})
+		})
+	}
+
+	return report, g.Wait()
+}
+
+// newCollection creates the state shared by the collectors of a run,
+// resources are only kept for the kinds another collector depends on.
+func (s *Scrapper) newCollection(report *RunReport) *Collection {
+	indexed := map[Kind]bool{}
+	for _, c := range s.collectors {
+		for _, dep := range s.dependsOn(c) {
+			indexed[dep] = true
+		}
+	}
+	return &Collection{
+		scrapper: s,
+		report:   report,
+		indexed:  indexed,
+		graph:    s.graph,
+		found:    map[Kind][]any{},
+	}
+}
+
+// kindConfig returns the settings of kind, values below 1 keep the default concurrency.
+func (s *Scrapper) kindConfig(kind Kind) kindConfig {
+	c := s.kindConfigs[kind]
+	c.concurrency = workers(c.concurrency, defaultKindConcurrency)
+	return c
+}
+
+// dependsOn returns the kinds a collector waits for, scoped scrapes resolve the resource groups before any other kind.
+func (s *Scrapper) dependsOn(c registration) []Kind {
+	if s.scope == nil || c.kind() == KindResourceGroup {
+		return c.dependsOn()
+	}
+	return append([]Kind{KindResourceGroup}, c.dependsOn()...)
+}
+
+// collect runs a collector, scoped scrapes list each resource group in scope with kinds that support it.
+// The other kinds are found from the kinds they depend on, or only collected for a kind depending on them.
+// Kinds listed from resource graph emit the rows queried for the subscription instead.
+func (s *Scrapper) collect(ctx context.Context, col *Collection, c registration) error {
+	if result, ok := col.graph[c.kind()]; ok {
+		return s.collectGraph(ctx, col, c, result)
+	}
+	client := s.clients[c.kind()]
+	if s.scope == nil || c.kind() == KindResourceGroup || !c.scopable() {
+		return c.collect(ctx, col, client)
+	}
+	for _, rg := range col.ResourceGroups() {
+		if err := c.collectResourceGroup(ctx, col, client, rg); err != nil {
+			return fmt.Errorf("resource group %s: %w", rg, err)
+		}
+	}
+	return nil
+}
+
+// scrapeKind runs fn bounded by the timeout configured for kind, recording the outcome in the report.
+// Failures are tagged with the kind and only returned when partial results are disabled.
+func (s *Scrapper) scrapeKind(ctx context.Context, report *RunReport, kind Kind, fn func(ctx context.Context) error) error {
+	if d, ok := s.kindTimeouts[kind]; ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, d)
+		defer cancel()
+	}
+
+	kr := report.Kinds[kind]
+	start := time.Now()
+	err := fn(withKindReport(ctx, kr))
 	if err != nil {
-		return nil, err
+		err = &KindError{Kind: kind, Err: err}
 	}
+	kr.finish(time.Since(start), err)
 
-	return &Scrapper{
-		resourceGroupClient:     rgc,
-		providersClient:         pc,
-		networksClient:          nc,
-		diskEncryptionSetClient: desc,
-		clusterClient:           cc,
-		nodePoolClient:          npc,
-	}, nil
+	if s.partial {
+		return nil
+	}
+	return err
 }
 
-func (s *Scrapper) Run() error {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	g, ctx := errgroup.WithContext(ctx)
-	g.Go(func() error {
-		return s.ListResourceGroups(ctx, consoleHandler[resource.ResourceGroup])
-	})
-	g.Go(func() error {
-		return s.ListProviders(ctx, consoleHandler[resource.Provider])
-	})
-	g.Go(func() error {
-		return s.ListVirtualNetworks(ctx, consoleHandler[network.VirtualNetwork])
-	})
-	g.Go(func() error {
-		return s.ListDiskEncryptionSets(ctx, consoleHandler[compute.DiskEncryptionSet])
-	})
-	g.Go(func() error {
-		return s.ListClusters(ctx, consoleHandler[container.ManagedCluster])
-	})
-
-	return g.Wait()
+// KindError reports which resource kind failed to scrape.
+type KindError struct {
+	Kind Kind
+	Err  error
 }
 
-func processPage[T any](page []*T, err error, pageHandler pageHandler[T]) error {
+func (e *KindError) Error() string {
+	if errors.Is(e.Err, context.DeadlineExceeded) {
+		return fmt.Sprintf("timed out scraping %s: %v", e.Kind, e.Err)
+	}
+	return fmt.Sprintf("failed to scrape %s: %v", e.Kind, e.Err)
+}
+
+func (e *KindError) Unwrap() error {
+	return e.Err
+}
+
+func processPage[T any](ctx context.Context, page []*T, pageHandler pageHandler[T]) error {
+	if kr := kindReportFromContext(ctx); kr != nil {
+		kr.addPage()
+	}
 	for _, v := range page {
-		if err = pageHandler(v); err != nil {
+		if err := pageHandler(v); err != nil {
 			return fmt.Errorf("failed to process page: %w", err)
 		}
 	}
 	return nil
 }
-
-func consoleHandler[T any](t *T) error {
-	return json.NewEncoder(os.Stdout).Encode(t)
-}
@@ -2,17 +2,16 @@ package scrapper_test
 
 import (
 	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
-	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
 	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
 	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
-	network "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v2"
 	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -33,42 +32,42 @@ func TestNewScraper(t *testing.T) {
 		},
 		{
 			name:          "fails if resource group client factory fails",
-			withFactories: []OptionsFunc{WithResourceGroupsFactory(brokenFactory[ResourceGroupsPager])},
+			withFactories: []OptionsFunc{WithResourceGroupsFactory(scrappertest.FailingFactory[ResourceGroupsPager](errors.New("failed to create client")))},
 			want: func(t *testing.T, scrapper *Scrapper, err error) {
 				assert.Error(t, err, "failed to create client")
 			},
 		},
 		{
 			name:          "fails if provider client factory fails",
-			withFactories: []OptionsFunc{WithProvidersFactory(brokenFactory[ProvidersPager])},
+			withFactories: []OptionsFunc{WithProvidersFactory(scrappertest.FailingFactory[ProvidersPager](errors.New("failed to create client")))},
 			want: func(t *testing.T, scrapper *Scrapper, err error) {
 				assert.Error(t, err, "failed to create client")
 			},
 		},
 		{
 			name:          "fails if virtual network client factory fails",
-			withFactories: []OptionsFunc{WithVirtualNetworksFactory(brokenFactory[VirtualNetworkPager])},
+			withFactories: []OptionsFunc{WithVirtualNetworksFactory(scrappertest.FailingFactory[VirtualNetworkPager](errors.New("failed to create client")))},
 			want: func(t *testing.T, scrapper *Scrapper, err error) {
 				assert.EqualError(t, err, "failed to create client")
 			},
 		},
 		{
 			name:          "fails if disk encryption set client factory fails",
-			withFactories: []OptionsFunc{WithDiskEncryptionSetFactory(brokenFactory[DiskEncryptionSetPager])},
+			withFactories: []OptionsFunc{WithDiskEncryptionSetFactory(scrappertest.FailingFactory[DiskEncryptionSetPager](errors.New("failed to create client")))},
 			want: func(t *testing.T, scrapper *Scrapper, err error) {
 				assert.EqualError(t, err, "failed to create client")
 			},
 		},
 		{
 			name:          "fails if cluster client factory fails",
-			withFactories: []OptionsFunc{WithClusterFactory(brokenFactory[ClusterPager])},
+			withFactories: []OptionsFunc{WithClusterFactory(scrappertest.FailingFactory[ClusterPager](errors.New("failed to create client")))},
 			want: func(t *testing.T, scrapper *Scrapper, err error) {
 				assert.EqualError(t, err, "failed to create client")
 			},
 		},
 		{
 			name:          "fails if node pool client factory fails",
-			withFactories: []OptionsFunc{WithNodePoolFactory(brokenFactory[NodePoolPager])},
+			withFactories: []OptionsFunc{WithNodePoolFactory(scrappertest.FailingFactory[NodePoolPager](errors.New("failed to create client")))},
 			want: func(t *testing.T, scrapper *Scrapper, err error) {
 				assert.EqualError(t, err, "failed to create client")
 			},
@@ -84,125 +83,146 @@ func TestNewScraper(t *testing.T) {
 
 func TestScrapper_Run(t *testing.T) {
 	type clients struct {
-		resourceGroupClient      ResourceGroupsPager
-		providersClient          ProvidersPager
-		networksClient           VirtualNetworkPager
-		diskEncryptionSetsClient DiskEncryptionSetPager
-		clusterClient            ClusterPager
+		clusterClient  ClusterPager
+		nodePoolClient NodePoolPager
 	}
 	tests := []struct {
 		name    string
 		clients clients
-		want    func(t *testing.T, err error)
+		want    func(t *testing.T, records []Record, err error)
 	}{
 		{
 			name: "Successful execution",
 			clients: clients{
-				resourceGroupClient: NewPager[resource.ResourceGroupsClientListOptions, resource.ResourceGroupsClientListResponse]{
-					item: &resource.ResourceGroupsClientListResponse{},
-				},
-				providersClient: NewPager[resource.ProvidersClientListOptions, resource.ProvidersClientListResponse]{
-					item: &resource.ProvidersClientListResponse{},
-				},
-				networksClient: NewPager[network.VirtualNetworksClientListAllOptions, network.VirtualNetworksClientListAllResponse]{
-					item: &network.VirtualNetworksClientListAllResponse{},
-				},
-				diskEncryptionSetsClient: NewPager[compute.DiskEncryptionSetsClientListOptions, compute.DiskEncryptionSetsClientListResponse]{
-					item: &compute.DiskEncryptionSetsClientListResponse{},
-				},
-				clusterClient: NewPager[container.ManagedClustersClientListOptions, container.ManagedClustersClientListResponse]{
-					item: &container.ManagedClustersClientListResponse{},
-				},
+				clusterClient: scrappertest.NewClusterPager(scrappertest.Items(&container.ManagedCluster{
+					ID: to("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/aks"),
+				})),
+				nodePoolClient: scrappertest.NewNodePoolPager(scrappertest.Items(&container.AgentPool{})),
 			},
-			want: func(t *testing.T, err error) {
+			want: func(t *testing.T, records []Record, err error) {
 				assert.NoError(t, err)
+				require.Len(t, records, 2)
+				assert.Equal(t, KindCluster, records[0].Kind)
+				assert.Equal(t, "sub", records[0].SubscriptionID)
+				assert.NotEmpty(t, records[0].RunID)
+				assert.False(t, records[0].ScrapedAt.IsZero())
+				assert.IsType(t, &container.ManagedCluster{}, records[0].Payload)
+				assert.Equal(t, KindNodePool, records[1].Kind)
+				require.IsType(t, &NodePool{}, records[1].Payload)
+				assert.Equal(t, "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/aks", records[1].Payload.(*NodePool).ClusterID)
 			},
 		},
 	}
 	for _, tt := range tests {
-		options := []OptionsFunc{
-			WithResourceGroupsFactory(func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (ResourceGroupsPager, error) {
-				return tt.clients.resourceGroupClient, nil
-			}),
-			WithProvidersFactory(func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (ProvidersPager, error) {
-				return tt.clients.providersClient, nil
-			}),
-			WithVirtualNetworksFactory(func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (VirtualNetworkPager, error) {
-				return tt.clients.networksClient, nil
-			}),
-			WithDiskEncryptionSetFactory(func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (DiskEncryptionSetPager, error) {
-				return tt.clients.diskEncryptionSetsClient, nil
-			}),
-			WithClusterFactory(func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (ClusterPager, error) {
-				return tt.clients.clusterClient, nil
-			}),
-		}
-
-		s, err := NewScrapper(nil, "nil", options...)
+		sink := NewMemorySink()
+		options := append(scrappertest.Empty(),
+			WithClusterFactory(scrappertest.Factory(tt.clients.clusterClient)),
+			WithNodePoolFactory(scrappertest.Factory(tt.clients.nodePoolClient)),
+			WithSink(sink),
+		)
+
+		s, err := NewScrapper(nil, "sub", options...)
 		require.NoError(t, err)
 
 		t.Run(tt.name, func(t *testing.T) {
-			tt.want(t, s.Run())
+			report, err := s.Run(context.Background())
+			require.NotNil(t, report)
+			assert.Equal(t, "sub", report.SubscriptionID)
+			tt.want(t, sink.Records(), err)
 		})
 	}
 }
 
-func testCred() az.TokenCredential {
-	return &azidentity.DefaultAzureCredential{}
-}
-
-func brokenFactory[T any](_ string, _ az.TokenCredential, _ *arm.ClientOptions) (T, error) {
-	return *new(T), errors.New("failed to create client")
-}
-
-type NewPager[O any, T any] struct {
-	item *T
-}
-
-func (n NewPager[O, T]) NewListPager(_ *O) *rt.Pager[T] {
-	return singleItemPager[T](n.item)
-}
-
-func (n NewPager[O, T]) NewListAllPager(opts *O) *rt.Pager[T] {
-	return n.NewListPager(opts)
-}
-
-type NewNodePager[O any, T any] struct {
-	item *T
-}
-
-func (n NewNodePager[O, T]) NewListPager(_ string, _ string, _ *O) *rt.Pager[T] {
-	return singleItemPager[T](n.item)
-}
-
-func singleItemPager[T any](item *T) *rt.Pager[T] {
-	return rt.NewPager[T](rt.PagingHandler[T]{
-		More:    func(t T) bool { return false },
-		Fetcher: func(ctx context.Context, t *T) (T, error) { return *item, nil },
-	})
-}
-
-type FailPager[O any, T any] struct {
-}
-
-func (f FailPager[O, T]) NewListPager(_ *O) *rt.Pager[T] {
-	return errorPager[T]()
-}
-
-func (f FailPager[O, T]) NewListAllPager(opts *O) *rt.Pager[T] {
-	return f.NewListPager(opts)
+func TestScrapper_RunReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []OptionsFunc
+		want    func(t *testing.T, report *RunReport, records []Record, err error)
+	}{
+		{
+			name: "fail fast returns the first failing kind",
+			want: func(t *testing.T, report *RunReport, records []Record, err error) {
+				var kindErr *KindError
+				require.True(t, errors.As(err, &kindErr))
+				assert.Equal(t, KindDiskEncryptionSet, kindErr.Kind)
+				assert.True(t, report.Failed())
+			},
+		},
+		{
+			name:    "partial results keeps scraping the other kinds",
+			options: []OptionsFunc{WithPartialResults()},
+			want: func(t *testing.T, report *RunReport, records []Record, err error) {
+				require.NoError(t, err)
+				assert.Len(t, records, 1)
+				assert.ErrorContains(t, report.Err(), "failed to scrape diskEncryptionSet")
+				assert.Error(t, report.Kinds[KindDiskEncryptionSet].Err)
+				assert.NoError(t, report.Kinds[KindCluster].Err)
+				assert.Equal(t, 1, report.Kinds[KindCluster].Records)
+				assert.Equal(t, 1, report.Kinds[KindCluster].Pages)
+				assert.Equal(t, 1, report.Kinds[KindProvider].Pages)
+				assert.Equal(t, 0, report.Kinds[KindProvider].Records)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sink := NewMemorySink()
+			options := append(oneClusterPerSubscription(),
+				WithDiskEncryptionSetFactory(scrappertest.Factory[DiskEncryptionSetPager](scrappertest.NewDiskEncryptionSetPager(
+					scrappertest.Fail[compute.DiskEncryptionSet](errors.New("failed to iterate"))))),
+				WithSink(sink),
+			)
+			s, err := NewScrapper(testCred(), "sub", append(options, tt.options...)...)
+			require.NoError(t, err)
+			report, err := s.Run(context.Background())
+			tt.want(t, report, sink.Records(), err)
+		})
+	}
 }
 
-type FailNodePager[O any, T any] struct {
+func TestScrapper_RunTimeouts(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []OptionsFunc
+		want    func(t *testing.T, err error)
+	}{
+		{
+			name:    "kind timeout reports the kind",
+			options: []OptionsFunc{WithKindTimeout(KindProvider, 10*time.Millisecond)},
+			want: func(t *testing.T, err error) {
+				var kindErr *KindError
+				require.True(t, errors.As(err, &kindErr))
+				assert.Equal(t, KindProvider, kindErr.Kind)
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+				assert.ErrorContains(t, err, "timed out scraping provider")
+			},
+		},
+		{
+			name:    "overall timeout bounds the run",
+			options: []OptionsFunc{WithTimeout(10 * time.Millisecond)},
+			want: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append(oneClusterPerSubscription(),
+				WithProvidersFactory(scrappertest.Factory[ProvidersPager](scrappertest.NewProvidersPager(scrappertest.Block[resource.Provider]()))),
+				WithSink(NewMemorySink()),
+			)
+			s, err := NewScrapper(testCred(), "sub", append(options, tt.options...)...)
+			require.NoError(t, err)
+			_, err = s.Run(context.Background())
+			tt.want(t, err)
+		})
+	}
 }
 
-func (f FailNodePager[O, T]) NewListPager(_ string, _ string, _ *O) *rt.Pager[T] {
-	return errorPager[T]()
+func testCred() az.TokenCredential {
+	return &azidentity.DefaultAzureCredential{}
 }
 
-func errorPager[T any]() *rt.Pager[T] {
-	return rt.NewPager[T](rt.PagingHandler[T]{
-		More:    func(t T) bool { return false },
-		Fetcher: func(ctx context.Context, t *T) (T, error) { return *new(T), errors.New("failed to iterate") },
-	})
+func to[T any](v T) *T {
+	return &v
 }
@@ -0,0 +1,68 @@
+package scrappertest
+
+import (
+	"azure-scrapper/internal/scrapper"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+)
+
+// emptyFake installs the empty fake client of a kind.
+type emptyFake func(kind scrapper.Kind, created func(scrapper.Kind)) scrapper.OptionsFunc
+
+// fakes holds the empty fake of every registered kind, kinds derived from the resources of other kinds have no client.
+var fakes = map[scrapper.Kind]emptyFake{
+	scrapper.KindResourceGroup:        fake(func() scrapper.ResourceGroupsPager { return NewResourceGroupsPager() }),
+	scrapper.KindProvider:             fake(func() scrapper.ProvidersPager { return NewProvidersPager() }),
+	scrapper.KindVirtualNetwork:       fake(func() scrapper.VirtualNetworkPager { return NewVirtualNetworkPager() }),
+	scrapper.KindSubnet:               nil,
+	scrapper.KindDiskEncryptionSet:    fake(func() scrapper.DiskEncryptionSetPager { return NewDiskEncryptionSetPager() }),
+	scrapper.KindCluster:              fake(func() scrapper.ClusterPager { return NewClusterPager() }),
+	scrapper.KindNodePool:             fake(func() scrapper.NodePoolPager { return NewNodePoolPager() }),
+	scrapper.KindVirtualMachine:       fake(func() scrapper.VirtualMachinePager { return NewVirtualMachinePager() }),
+	scrapper.KindScaleSet:             fake(func() scrapper.ScaleSetPager { return NewScaleSetPager() }),
+	scrapper.KindScaleSetInstance:     fake(func() scrapper.ScaleSetInstancePager { return NewScaleSetInstancePager() }),
+	scrapper.KindNetworkSecurityGroup: fake(func() scrapper.NetworkSecurityGroupPager { return NewNetworkSecurityGroupPager() }),
+	scrapper.KindSecurityRule:         nil,
+	scrapper.KindRouteTable:           fake(func() scrapper.RouteTablePager { return NewRouteTablePager() }),
+	scrapper.KindDisk:                 fake(func() scrapper.DiskPager { return NewDiskPager() }),
+	scrapper.KindSnapshot:             fake(func() scrapper.SnapshotPager { return NewSnapshotPager() }),
+	scrapper.KindEncryptionFinding:    nil,
+	scrapper.KindGenericResource:      fake(func() scrapper.GenericResourcePager { return NewGenericResourcePager() }),
+}
+
+func fake[C any](newClient func() C) emptyFake {
+	return func(kind scrapper.Kind, created func(scrapper.Kind)) scrapper.OptionsFunc {
+		client := newClient()
+		return scrapper.WithFactory(kind, func(_ string, _ az.TokenCredential, _ *arm.ClientOptions) (C, error) {
+			if created != nil {
+				created(kind)
+			}
+			return client, nil
+		})
+	}
+}
+
+// Empty returns options replacing the client of every registered kind with a fake listing nothing, so a run never
+// reaches ARM. Options added afterwards replace the fakes of the kinds a test lists resources of.
+// Kinds registered outside of the scrapper get the Empty client of their collector, those without one keep their factory.
+func Empty() []scrapper.OptionsFunc {
+	return Recording(nil)
+}
+
+// Recording returns the options of Empty, created is called with the kind of every fake client the scrapper creates.
+func Recording(created func(scrapper.Kind)) []scrapper.OptionsFunc {
+	var opts []scrapper.OptionsFunc
+	for _, kind := range scrapper.Kinds() {
+		if f, ok := fakes[kind]; ok {
+			if f != nil {
+				opts = append(opts, f(kind, created))
+			}
+			continue
+		}
+		if opt, ok := scrapper.WithEmptyClient(kind, created); ok {
+			opts = append(opts, opt)
+		}
+	}
+	return opts
+}
@@ -0,0 +1,138 @@
+// Package scrappertest provides fakes of the clients the scrapper lists resources with,
+// so code embedding the scrapper can test whole runs without azure.
+package scrappertest
+
+import (
+	"azure-scrapper/internal/scrapper"
+	"context"
+	"sync"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+)
+
+// Page is a page served by a fake pager, a page with an error fails the listing once the pager reaches it.
+// A blocking page only fails once the context of the listing is done.
+type Page[T any] struct {
+	Items []*T
+	Err   error
+	Block bool
+}
+
+// Items returns a page serving the items.
+func Items[T any](items ...*T) Page[T] {
+	return Page[T]{Items: items}
+}
+
+// Fail returns a page failing with err.
+func Fail[T any](err error) Page[T] {
+	return Page[T]{Err: err}
+}
+
+// Block returns a page never served, fetching it waits for the context of the listing to be done.
+func Block[T any]() Page[T] {
+	return Page[T]{Block: true}
+}
+
+// Call is a recorded call to a fake pager, ResourceGroup is only set for resource group listings and node pools,
+// ResourceName for node pools.
+type Call[O any] struct {
+	ResourceGroup string
+	ResourceName  string
+	Options       *O
+}
+
+// pager serves canned pages of T wrapped in list responses R, and records how it is called.
+type pager[O any, R any, T any] struct {
+	wrap func(items []*T, nextLink *string) R
+
+	mu    sync.Mutex
+	calls []Call[O]
+}
+
+// Calls returns the calls received so far, in order.
+func (p *pager[O, R, T]) Calls() []Call[O] {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Call[O]{}, p.calls...)
+}
+
+func (p *pager[O, R, T]) list(call Call[O], pages []Page[T]) *rt.Pager[R] {
+	p.mu.Lock()
+	p.calls = append(p.calls, call)
+	p.mu.Unlock()
+
+	next := 0
+	return rt.NewPager[R](rt.PagingHandler[R]{
+		More: func(R) bool {
+			return next < len(pages)
+		},
+		Fetcher: func(ctx context.Context, _ *R) (R, error) {
+			if err := ctx.Err(); err != nil {
+				return *new(R), err
+			}
+			if next >= len(pages) {
+				return p.wrap(nil, nil), nil
+			}
+			page := pages[next]
+			next++
+			if page.Block {
+				<-ctx.Done()
+				return *new(R), ctx.Err()
+			}
+			if page.Err != nil {
+				return *new(R), page.Err
+			}
+			var nextLink *string
+			if next < len(pages) {
+				link := "next"
+				nextLink = &link
+			}
+			return p.wrap(page.Items, nextLink), nil
+		},
+	})
+}
+
+// groupPages holds the pages served when listing single resource groups, groups without pages are empty.
+type groupPages[T any] struct {
+	mu    sync.Mutex
+	pages map[string][]Page[T]
+}
+
+func (g *groupPages[T]) set(resourceGroup string, pages []Page[T]) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.pages == nil {
+		g.pages = map[string][]Page[T]{}
+	}
+	g.pages[resourceGroup] = pages
+}
+
+func (g *groupPages[T]) get(resourceGroup string) []Page[T] {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.pages[resourceGroup]
+}
+
+// Factory returns a client factory handing out client, for use with the scrapper With*Factory options.
+func Factory[C any](client C) scrapper.ClientFactory[C] {
+	return func(_ string, _ az.TokenCredential, _ *arm.ClientOptions) (C, error) {
+		return client, nil
+	}
+}
+
+// SubscriptionsFactory returns a subscription client factory handing out client, for use with
+// scrapper.WithSubscriptionsFactory.
+func SubscriptionsFactory(client scrapper.SubscriptionPager) scrapper.SubscriptionClientFactory {
+	return func(_ az.TokenCredential, _ *arm.ClientOptions) (scrapper.SubscriptionPager, error) {
+		return client, nil
+	}
+}
+
+// FailingFactory returns a client factory failing with err.
+func FailingFactory[C any](err error) scrapper.ClientFactory[C] {
+	return func(_ string, _ az.TokenCredential, _ *arm.ClientOptions) (C, error) {
+		return *new(C), err
+	}
+}
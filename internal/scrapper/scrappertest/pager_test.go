@@ -0,0 +1,217 @@
+package scrappertest_test
+
+import (
+	"azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	subscription "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagers(t *testing.T) {
+	clusterID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/%s"
+
+	tests := []struct {
+		name      string
+		clusters  *scrappertest.ClusterPager
+		nodePools *scrappertest.NodePoolPager
+		groups    *scrappertest.ResourceGroupsPager
+		expect    func(t *testing.T, report *scrapper.RunReport, err error, clusters *scrappertest.ClusterPager, nodePools *scrappertest.NodePoolPager)
+	}{
+		{
+			name: "pages are listed in order",
+			clusters: scrappertest.NewClusterPager(
+				scrappertest.Items(cluster(clusterID, "aks-1"), cluster(clusterID, "aks-2")),
+				scrappertest.Items(cluster(clusterID, "aks-3")),
+			),
+			nodePools: scrappertest.NewNodePoolPager(scrappertest.Items(&container.AgentPool{Name: to("system")})).
+				Cluster("rg", "aks-2",
+					scrappertest.Items(&container.AgentPool{Name: to("system")}),
+					scrappertest.Items(&container.AgentPool{Name: to("user")}),
+				),
+			groups: scrappertest.NewResourceGroupsPager(scrappertest.Items(&resource.ResourceGroup{Name: to("rg")})),
+			expect: func(t *testing.T, report *scrapper.RunReport, err error, clusters *scrappertest.ClusterPager, nodePools *scrappertest.NodePoolPager) {
+				require.NoError(t, err)
+				assert.Equal(t, 3, report.Kinds[scrapper.KindCluster].Records)
+				assert.Equal(t, 2, report.Kinds[scrapper.KindCluster].Pages)
+				assert.Equal(t, 4, report.Kinds[scrapper.KindNodePool].Records)
+				assert.Len(t, clusters.Calls(), 1)
+
+				var names []string
+				for _, c := range nodePools.Calls() {
+					assert.Equal(t, "rg", c.ResourceGroup)
+					names = append(names, c.ResourceName)
+				}
+				assert.ElementsMatch(t, []string{"aks-1", "aks-2", "aks-3"}, names)
+			},
+		},
+		{
+			name: "mid-stream error fails the kind",
+			clusters: scrappertest.NewClusterPager(
+				scrappertest.Items(cluster(clusterID, "aks-1")),
+				scrappertest.Fail[container.ManagedCluster](errors.New("boom")),
+				scrappertest.Items(cluster(clusterID, "aks-2")),
+			),
+			nodePools: scrappertest.NewNodePoolPager(),
+			groups:    scrappertest.NewResourceGroupsPager(),
+			expect: func(t *testing.T, report *scrapper.RunReport, err error, _ *scrappertest.ClusterPager, nodePools *scrappertest.NodePoolPager) {
+				assert.ErrorContains(t, err, "boom")
+				assert.Equal(t, 1, report.Kinds[scrapper.KindCluster].Records)
+			},
+		},
+		{
+			name: "blocking page waits for the kind timeout",
+			clusters: scrappertest.NewClusterPager(
+				scrappertest.Items(cluster(clusterID, "aks-1")),
+				scrappertest.Block[container.ManagedCluster](),
+			),
+			nodePools: scrappertest.NewNodePoolPager(),
+			groups:    scrappertest.NewResourceGroupsPager(),
+			expect: func(t *testing.T, report *scrapper.RunReport, err error, _ *scrappertest.ClusterPager, _ *scrappertest.NodePoolPager) {
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+				assert.Equal(t, 1, report.Kinds[scrapper.KindCluster].Records)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := scrapper.NewScrapper(nil, "sub", append(scrappertest.Empty(),
+				scrapper.WithSink(scrapper.NewMemorySink()),
+				scrapper.WithClusterFactory(scrappertest.Factory[scrapper.ClusterPager](tt.clusters)),
+				scrapper.WithNodePoolFactory(scrappertest.Factory[scrapper.NodePoolPager](tt.nodePools)),
+				scrapper.WithResourceGroupsFactory(scrappertest.Factory[scrapper.ResourceGroupsPager](tt.groups)),
+				scrapper.WithKindTimeout(scrapper.KindCluster, 50*time.Millisecond),
+			)...)
+			require.NoError(t, err)
+
+			report, err := s.Run(context.Background())
+			tt.expect(t, report, err, tt.clusters, tt.nodePools)
+		})
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	sink := scrapper.NewMemorySink()
+	s, err := scrapper.NewScrapper(nil, "sub", append(scrappertest.Empty(), scrapper.WithSink(sink), scrapper.WithKinds(scrapper.Kinds()...))...)
+	require.NoError(t, err)
+
+	report, err := s.Run(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, report.Kinds, len(scrapper.Kinds()))
+	assert.Empty(t, sink.Records())
+}
+
+func TestSubscriptionsFactory(t *testing.T) {
+	subscriptions := scrappertest.NewSubscriptionPager(scrappertest.Items(
+		&subscription.Subscription{SubscriptionID: to("sub-a"), State: to(subscription.SubscriptionStateEnabled)},
+	))
+	sink := scrapper.NewMemorySink()
+	s, err := scrapper.NewMultiScrapper(nil, nil, append(scrappertest.Empty(),
+		scrapper.WithSink(sink),
+		scrapper.WithSubscriptionsFactory(scrappertest.SubscriptionsFactory(subscriptions)),
+		scrapper.WithClusterFactory(scrappertest.Factory[scrapper.ClusterPager](scrappertest.NewClusterPager(
+			scrappertest.Items(cluster("/subscriptions/sub-a/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/%s", "aks")),
+		))),
+	)...)
+	require.NoError(t, err)
+
+	_, err = s.Run(context.Background())
+	require.NoError(t, err)
+	require.Len(t, sink.Records(), 1)
+	assert.Equal(t, "sub-a", sink.Records()[0].SubscriptionID)
+	assert.Len(t, subscriptions.Calls(), 1)
+}
+
+// widgetPager is the client of a kind registered outside of the scrapper.
+type widgetPager interface {
+	Widgets() []string
+}
+
+type widgets []string
+
+func (w widgets) Widgets() []string {
+	return w
+}
+
+const (
+	kindWidget       scrapper.Kind = "testWidget"
+	kindWidgetReport scrapper.Kind = "testWidgetReport"
+)
+
+func init() {
+	scrapper.Register(scrapper.Collector[widgetPager]{
+		Kind:     kindWidget,
+		Optional: true,
+		Factory: func(_ string, _ az.TokenCredential, _ *arm.ClientOptions) (widgetPager, error) {
+			return nil, errors.New("reached azure")
+		},
+		Collect: func(_ context.Context, c *scrapper.Collection, client widgetPager) error {
+			for _, w := range client.Widgets() {
+				w := w
+				if err := c.Emit(kindWidget, &w); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Empty: func() widgetPager { return widgets{} },
+	})
+	scrapper.Register(scrapper.Collector[any]{
+		Kind:      kindWidgetReport,
+		DependsOn: []scrapper.Kind{kindWidget},
+		Optional:  true,
+		Collect: func(_ context.Context, _ *scrapper.Collection, _ any) error {
+			return nil
+		},
+	})
+}
+
+func TestEmpty_KindsRegisteredOutsideTheScrapper(t *testing.T) {
+	var created []scrapper.Kind
+	var mu sync.Mutex
+	record := func(kind scrapper.Kind) {
+		mu.Lock()
+		defer mu.Unlock()
+		created = append(created, kind)
+	}
+
+	sink := scrapper.NewMemorySink()
+	s, err := scrapper.NewScrapper(nil, "sub", append(scrappertest.Recording(record),
+		scrapper.WithSink(sink),
+		scrapper.WithKinds(kindWidget, kindWidgetReport),
+	)...)
+	require.NoError(t, err)
+
+	report, err := s.Run(context.Background())
+	require.NoError(t, err)
+	assert.NoError(t, report.Err())
+	assert.Contains(t, created, kindWidget)
+	assert.Empty(t, sink.Records())
+}
+
+func TestFailingFactory(t *testing.T) {
+	_, err := scrapper.NewScrapper(nil, "sub",
+		scrapper.WithSink(scrapper.NewMemorySink()),
+		scrapper.WithClusterFactory(scrappertest.FailingFactory[scrapper.ClusterPager](errors.New("no client"))),
+	)
+	assert.EqualError(t, err, "no client")
+}
+
+func cluster(idFormat string, name string) *container.ManagedCluster {
+	return &container.ManagedCluster{ID: to(fmt.Sprintf(idFormat, name)), Name: to(name)}
+}
+
+func to[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,659 @@
+package scrappertest
+
+import (
+	"azure-scrapper/internal/scrapper"
+	"context"
+	"sync"
+
+	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	network "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v2"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	subscription "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+)
+
+var (
+	_ scrapper.SubscriptionPager         = (*SubscriptionPager)(nil)
+	_ scrapper.ResourceGroupsPager       = (*ResourceGroupsPager)(nil)
+	_ scrapper.ProvidersPager            = (*ProvidersPager)(nil)
+	_ scrapper.VirtualNetworkPager       = (*VirtualNetworkPager)(nil)
+	_ scrapper.DiskEncryptionSetPager    = (*DiskEncryptionSetPager)(nil)
+	_ scrapper.ClusterPager              = (*ClusterPager)(nil)
+	_ scrapper.NodePoolPager             = (*NodePoolPager)(nil)
+	_ scrapper.VirtualMachinePager       = (*VirtualMachinePager)(nil)
+	_ scrapper.ScaleSetPager             = (*ScaleSetPager)(nil)
+	_ scrapper.ScaleSetInstancePager     = (*ScaleSetInstancePager)(nil)
+	_ scrapper.NetworkSecurityGroupPager = (*NetworkSecurityGroupPager)(nil)
+	_ scrapper.RouteTablePager           = (*RouteTablePager)(nil)
+	_ scrapper.DiskPager                 = (*DiskPager)(nil)
+	_ scrapper.SnapshotPager             = (*SnapshotPager)(nil)
+	_ scrapper.GenericResourcePager      = (*GenericResourcePager)(nil)
+)
+
+// SubscriptionPager is a fake scrapper.SubscriptionPager.
+type SubscriptionPager struct {
+	*pager[subscription.ClientListOptions, subscription.ClientListResponse, subscription.Subscription]
+	pages []Page[subscription.Subscription]
+}
+
+// NewSubscriptionPager serves the pages on every listing.
+func NewSubscriptionPager(pages ...Page[subscription.Subscription]) *SubscriptionPager {
+	return &SubscriptionPager{
+		pager: &pager[subscription.ClientListOptions, subscription.ClientListResponse, subscription.Subscription]{
+			wrap: func(items []*subscription.Subscription, nextLink *string) subscription.ClientListResponse {
+				return subscription.ClientListResponse{SubscriptionListResult: subscription.SubscriptionListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages: pages,
+	}
+}
+
+func (p *SubscriptionPager) NewListPager(options *subscription.ClientListOptions) *rt.Pager[subscription.ClientListResponse] {
+	return p.list(Call[subscription.ClientListOptions]{Options: options}, p.pages)
+}
+
+// ResourceGroupsPager is a fake scrapper.ResourceGroupsPager.
+type ResourceGroupsPager struct {
+	*pager[resource.ResourceGroupsClientListOptions, resource.ResourceGroupsClientListResponse, resource.ResourceGroup]
+	pages []Page[resource.ResourceGroup]
+}
+
+// NewResourceGroupsPager serves the pages on every listing.
+func NewResourceGroupsPager(pages ...Page[resource.ResourceGroup]) *ResourceGroupsPager {
+	return &ResourceGroupsPager{
+		pager: &pager[resource.ResourceGroupsClientListOptions, resource.ResourceGroupsClientListResponse, resource.ResourceGroup]{
+			wrap: func(items []*resource.ResourceGroup, nextLink *string) resource.ResourceGroupsClientListResponse {
+				return resource.ResourceGroupsClientListResponse{ResourceGroupListResult: resource.ResourceGroupListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages: pages,
+	}
+}
+
+func (p *ResourceGroupsPager) NewListPager(options *resource.ResourceGroupsClientListOptions) *rt.Pager[resource.ResourceGroupsClientListResponse] {
+	return p.list(Call[resource.ResourceGroupsClientListOptions]{Options: options}, p.pages)
+}
+
+// ProvidersPager is a fake scrapper.ProvidersPager.
+type ProvidersPager struct {
+	*pager[resource.ProvidersClientListOptions, resource.ProvidersClientListResponse, resource.Provider]
+	pages []Page[resource.Provider]
+}
+
+// NewProvidersPager serves the pages on every listing.
+func NewProvidersPager(pages ...Page[resource.Provider]) *ProvidersPager {
+	return &ProvidersPager{
+		pager: &pager[resource.ProvidersClientListOptions, resource.ProvidersClientListResponse, resource.Provider]{
+			wrap: func(items []*resource.Provider, nextLink *string) resource.ProvidersClientListResponse {
+				return resource.ProvidersClientListResponse{ProviderListResult: resource.ProviderListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages: pages,
+	}
+}
+
+func (p *ProvidersPager) NewListPager(options *resource.ProvidersClientListOptions) *rt.Pager[resource.ProvidersClientListResponse] {
+	return p.list(Call[resource.ProvidersClientListOptions]{Options: options}, p.pages)
+}
+
+// VirtualNetworkPager is a fake scrapper.VirtualNetworkPager.
+type VirtualNetworkPager struct {
+	*pager[network.VirtualNetworksClientListAllOptions, network.VirtualNetworksClientListAllResponse, network.VirtualNetwork]
+	pages  []Page[network.VirtualNetwork]
+	groups *pager[network.VirtualNetworksClientListOptions, network.VirtualNetworksClientListResponse, network.VirtualNetwork]
+	groupPages[network.VirtualNetwork]
+}
+
+// NewVirtualNetworkPager serves the pages on every listing.
+func NewVirtualNetworkPager(pages ...Page[network.VirtualNetwork]) *VirtualNetworkPager {
+	return &VirtualNetworkPager{
+		pager: &pager[network.VirtualNetworksClientListAllOptions, network.VirtualNetworksClientListAllResponse, network.VirtualNetwork]{
+			wrap: func(items []*network.VirtualNetwork, nextLink *string) network.VirtualNetworksClientListAllResponse {
+				return network.VirtualNetworksClientListAllResponse{VirtualNetworkListResult: network.VirtualNetworkListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages: pages,
+		groups: &pager[network.VirtualNetworksClientListOptions, network.VirtualNetworksClientListResponse, network.VirtualNetwork]{
+			wrap: func(items []*network.VirtualNetwork, nextLink *string) network.VirtualNetworksClientListResponse {
+				return network.VirtualNetworksClientListResponse{VirtualNetworkListResult: network.VirtualNetworkListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+	}
+}
+
+// ResourceGroup sets the pages served when listing a single resource group.
+func (p *VirtualNetworkPager) ResourceGroup(name string, pages ...Page[network.VirtualNetwork]) *VirtualNetworkPager {
+	p.set(name, pages)
+	return p
+}
+
+// GroupCalls returns the resource group listings received so far, in order.
+func (p *VirtualNetworkPager) GroupCalls() []Call[network.VirtualNetworksClientListOptions] {
+	return p.groups.Calls()
+}
+
+func (p *VirtualNetworkPager) NewListAllPager(options *network.VirtualNetworksClientListAllOptions) *rt.Pager[network.VirtualNetworksClientListAllResponse] {
+	return p.list(Call[network.VirtualNetworksClientListAllOptions]{Options: options}, p.pages)
+}
+
+func (p *VirtualNetworkPager) NewListPager(resourceGroupName string, options *network.VirtualNetworksClientListOptions) *rt.Pager[network.VirtualNetworksClientListResponse] {
+	return p.groups.list(Call[network.VirtualNetworksClientListOptions]{ResourceGroup: resourceGroupName, Options: options}, p.get(resourceGroupName))
+}
+
+// DiskEncryptionSetPager is a fake scrapper.DiskEncryptionSetPager.
+type DiskEncryptionSetPager struct {
+	*pager[compute.DiskEncryptionSetsClientListOptions, compute.DiskEncryptionSetsClientListResponse, compute.DiskEncryptionSet]
+	pages  []Page[compute.DiskEncryptionSet]
+	groups *pager[compute.DiskEncryptionSetsClientListByResourceGroupOptions, compute.DiskEncryptionSetsClientListByResourceGroupResponse, compute.DiskEncryptionSet]
+	groupPages[compute.DiskEncryptionSet]
+}
+
+// NewDiskEncryptionSetPager serves the pages on every listing.
+func NewDiskEncryptionSetPager(pages ...Page[compute.DiskEncryptionSet]) *DiskEncryptionSetPager {
+	return &DiskEncryptionSetPager{
+		pager: &pager[compute.DiskEncryptionSetsClientListOptions, compute.DiskEncryptionSetsClientListResponse, compute.DiskEncryptionSet]{
+			wrap: func(items []*compute.DiskEncryptionSet, nextLink *string) compute.DiskEncryptionSetsClientListResponse {
+				return compute.DiskEncryptionSetsClientListResponse{DiskEncryptionSetList: compute.DiskEncryptionSetList{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages: pages,
+		groups: &pager[compute.DiskEncryptionSetsClientListByResourceGroupOptions, compute.DiskEncryptionSetsClientListByResourceGroupResponse, compute.DiskEncryptionSet]{
+			wrap: func(items []*compute.DiskEncryptionSet, nextLink *string) compute.DiskEncryptionSetsClientListByResourceGroupResponse {
+				return compute.DiskEncryptionSetsClientListByResourceGroupResponse{DiskEncryptionSetList: compute.DiskEncryptionSetList{Value: items, NextLink: nextLink}}
+			},
+		},
+	}
+}
+
+// ResourceGroup sets the pages served when listing a single resource group.
+func (p *DiskEncryptionSetPager) ResourceGroup(name string, pages ...Page[compute.DiskEncryptionSet]) *DiskEncryptionSetPager {
+	p.set(name, pages)
+	return p
+}
+
+// GroupCalls returns the resource group listings received so far, in order.
+func (p *DiskEncryptionSetPager) GroupCalls() []Call[compute.DiskEncryptionSetsClientListByResourceGroupOptions] {
+	return p.groups.Calls()
+}
+
+func (p *DiskEncryptionSetPager) NewListPager(options *compute.DiskEncryptionSetsClientListOptions) *rt.Pager[compute.DiskEncryptionSetsClientListResponse] {
+	return p.list(Call[compute.DiskEncryptionSetsClientListOptions]{Options: options}, p.pages)
+}
+
+func (p *DiskEncryptionSetPager) NewListByResourceGroupPager(resourceGroupName string, options *compute.DiskEncryptionSetsClientListByResourceGroupOptions) *rt.Pager[compute.DiskEncryptionSetsClientListByResourceGroupResponse] {
+	return p.groups.list(Call[compute.DiskEncryptionSetsClientListByResourceGroupOptions]{ResourceGroup: resourceGroupName, Options: options}, p.get(resourceGroupName))
+}
+
+// ClusterPager is a fake scrapper.ClusterPager.
+type ClusterPager struct {
+	*pager[container.ManagedClustersClientListOptions, container.ManagedClustersClientListResponse, container.ManagedCluster]
+	pages  []Page[container.ManagedCluster]
+	groups *pager[container.ManagedClustersClientListByResourceGroupOptions, container.ManagedClustersClientListByResourceGroupResponse, container.ManagedCluster]
+	groupPages[container.ManagedCluster]
+}
+
+// NewClusterPager serves the pages on every listing.
+func NewClusterPager(pages ...Page[container.ManagedCluster]) *ClusterPager {
+	return &ClusterPager{
+		pager: &pager[container.ManagedClustersClientListOptions, container.ManagedClustersClientListResponse, container.ManagedCluster]{
+			wrap: func(items []*container.ManagedCluster, nextLink *string) container.ManagedClustersClientListResponse {
+				return container.ManagedClustersClientListResponse{ManagedClusterListResult: container.ManagedClusterListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages: pages,
+		groups: &pager[container.ManagedClustersClientListByResourceGroupOptions, container.ManagedClustersClientListByResourceGroupResponse, container.ManagedCluster]{
+			wrap: func(items []*container.ManagedCluster, nextLink *string) container.ManagedClustersClientListByResourceGroupResponse {
+				return container.ManagedClustersClientListByResourceGroupResponse{ManagedClusterListResult: container.ManagedClusterListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+	}
+}
+
+// ResourceGroup sets the pages served when listing a single resource group.
+func (p *ClusterPager) ResourceGroup(name string, pages ...Page[container.ManagedCluster]) *ClusterPager {
+	p.set(name, pages)
+	return p
+}
+
+// GroupCalls returns the resource group listings received so far, in order.
+func (p *ClusterPager) GroupCalls() []Call[container.ManagedClustersClientListByResourceGroupOptions] {
+	return p.groups.Calls()
+}
+
+func (p *ClusterPager) NewListPager(options *container.ManagedClustersClientListOptions) *rt.Pager[container.ManagedClustersClientListResponse] {
+	return p.list(Call[container.ManagedClustersClientListOptions]{Options: options}, p.pages)
+}
+
+func (p *ClusterPager) NewListByResourceGroupPager(resourceGroupName string, options *container.ManagedClustersClientListByResourceGroupOptions) *rt.Pager[container.ManagedClustersClientListByResourceGroupResponse] {
+	return p.groups.list(Call[container.ManagedClustersClientListByResourceGroupOptions]{ResourceGroup: resourceGroupName, Options: options}, p.get(resourceGroupName))
+}
+
+// NodePoolPager is a fake scrapper.NodePoolPager, it serves its default pages for every cluster
+// unless pages were set for the cluster with Cluster.
+type NodePoolPager struct {
+	*pager[container.AgentPoolsClientListOptions, container.AgentPoolsClientListResponse, container.AgentPool]
+	pages []Page[container.AgentPool]
+
+	mu       sync.Mutex
+	clusters map[[2]string][]Page[container.AgentPool]
+}
+
+// NewNodePoolPager serves the pages when listing the node pools of any cluster.
+func NewNodePoolPager(pages ...Page[container.AgentPool]) *NodePoolPager {
+	return &NodePoolPager{
+		pager: &pager[container.AgentPoolsClientListOptions, container.AgentPoolsClientListResponse, container.AgentPool]{
+			wrap: func(items []*container.AgentPool, nextLink *string) container.AgentPoolsClientListResponse {
+				return container.AgentPoolsClientListResponse{AgentPoolListResult: container.AgentPoolListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages:    pages,
+		clusters: map[[2]string][]Page[container.AgentPool]{},
+	}
+}
+
+// Cluster sets the pages served when listing the node pools of a single cluster.
+func (p *NodePoolPager) Cluster(resourceGroup string, name string, pages ...Page[container.AgentPool]) *NodePoolPager {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clusters[[2]string{resourceGroup, name}] = pages
+	return p
+}
+
+func (p *NodePoolPager) NewListPager(resourceGroupName string, resourceName string, options *container.AgentPoolsClientListOptions) *rt.Pager[container.AgentPoolsClientListResponse] {
+	p.mu.Lock()
+	pages, ok := p.clusters[[2]string{resourceGroupName, resourceName}]
+	p.mu.Unlock()
+	if !ok {
+		pages = p.pages
+	}
+	return p.list(Call[container.AgentPoolsClientListOptions]{ResourceGroup: resourceGroupName, ResourceName: resourceName, Options: options}, pages)
+}
+
+// VirtualMachinePager is a fake scrapper.VirtualMachinePager, virtual machines without an instance view set with
+// SetInstanceView have an empty one.
+type VirtualMachinePager struct {
+	*pager[compute.VirtualMachinesClientListAllOptions, compute.VirtualMachinesClientListAllResponse, compute.VirtualMachine]
+	pages  []Page[compute.VirtualMachine]
+	groups *pager[compute.VirtualMachinesClientListOptions, compute.VirtualMachinesClientListResponse, compute.VirtualMachine]
+	groupPages[compute.VirtualMachine]
+
+	mu        sync.Mutex
+	views     map[[2]string]*compute.VirtualMachineInstanceView
+	viewFails map[[2]string]error
+}
+
+// NewVirtualMachinePager serves the pages on every subscription wide listing.
+func NewVirtualMachinePager(pages ...Page[compute.VirtualMachine]) *VirtualMachinePager {
+	return &VirtualMachinePager{
+		pager: &pager[compute.VirtualMachinesClientListAllOptions, compute.VirtualMachinesClientListAllResponse, compute.VirtualMachine]{
+			wrap: func(items []*compute.VirtualMachine, nextLink *string) compute.VirtualMachinesClientListAllResponse {
+				return compute.VirtualMachinesClientListAllResponse{VirtualMachineListResult: compute.VirtualMachineListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages: pages,
+		groups: &pager[compute.VirtualMachinesClientListOptions, compute.VirtualMachinesClientListResponse, compute.VirtualMachine]{
+			wrap: func(items []*compute.VirtualMachine, nextLink *string) compute.VirtualMachinesClientListResponse {
+				return compute.VirtualMachinesClientListResponse{VirtualMachineListResult: compute.VirtualMachineListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+		views:     map[[2]string]*compute.VirtualMachineInstanceView{},
+		viewFails: map[[2]string]error{},
+	}
+}
+
+// ResourceGroup sets the pages served when listing a single resource group.
+func (p *VirtualMachinePager) ResourceGroup(name string, pages ...Page[compute.VirtualMachine]) *VirtualMachinePager {
+	p.set(name, pages)
+	return p
+}
+
+// SetInstanceView sets the instance view of a virtual machine.
+func (p *VirtualMachinePager) SetInstanceView(resourceGroup string, name string, view *compute.VirtualMachineInstanceView) *VirtualMachinePager {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.views[[2]string{resourceGroup, name}] = view
+	return p
+}
+
+// FailInstanceView makes reading the instance view of a virtual machine fail with err.
+func (p *VirtualMachinePager) FailInstanceView(resourceGroup string, name string, err error) *VirtualMachinePager {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.viewFails[[2]string{resourceGroup, name}] = err
+	return p
+}
+
+// GroupCalls returns the resource group listings received so far, in order.
+func (p *VirtualMachinePager) GroupCalls() []Call[compute.VirtualMachinesClientListOptions] {
+	return p.groups.Calls()
+}
+
+func (p *VirtualMachinePager) NewListAllPager(options *compute.VirtualMachinesClientListAllOptions) *rt.Pager[compute.VirtualMachinesClientListAllResponse] {
+	return p.list(Call[compute.VirtualMachinesClientListAllOptions]{Options: options}, p.pages)
+}
+
+func (p *VirtualMachinePager) NewListPager(resourceGroupName string, options *compute.VirtualMachinesClientListOptions) *rt.Pager[compute.VirtualMachinesClientListResponse] {
+	return p.groups.list(Call[compute.VirtualMachinesClientListOptions]{ResourceGroup: resourceGroupName, Options: options}, p.get(resourceGroupName))
+}
+
+func (p *VirtualMachinePager) InstanceView(ctx context.Context, resourceGroupName string, vmName string, _ *compute.VirtualMachinesClientInstanceViewOptions) (compute.VirtualMachinesClientInstanceViewResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return compute.VirtualMachinesClientInstanceViewResponse{}, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var resp compute.VirtualMachinesClientInstanceViewResponse
+	if err := p.viewFails[[2]string{resourceGroupName, vmName}]; err != nil {
+		return resp, err
+	}
+	if view := p.views[[2]string{resourceGroupName, vmName}]; view != nil {
+		resp.VirtualMachineInstanceView = *view
+	}
+	return resp, nil
+}
+
+// ScaleSetPager is a fake scrapper.ScaleSetPager.
+type ScaleSetPager struct {
+	*pager[compute.VirtualMachineScaleSetsClientListAllOptions, compute.VirtualMachineScaleSetsClientListAllResponse, compute.VirtualMachineScaleSet]
+	pages  []Page[compute.VirtualMachineScaleSet]
+	groups *pager[compute.VirtualMachineScaleSetsClientListOptions, compute.VirtualMachineScaleSetsClientListResponse, compute.VirtualMachineScaleSet]
+	groupPages[compute.VirtualMachineScaleSet]
+}
+
+// NewScaleSetPager serves the pages on every subscription wide listing.
+func NewScaleSetPager(pages ...Page[compute.VirtualMachineScaleSet]) *ScaleSetPager {
+	return &ScaleSetPager{
+		pager: &pager[compute.VirtualMachineScaleSetsClientListAllOptions, compute.VirtualMachineScaleSetsClientListAllResponse, compute.VirtualMachineScaleSet]{
+			wrap: func(items []*compute.VirtualMachineScaleSet, nextLink *string) compute.VirtualMachineScaleSetsClientListAllResponse {
+				return compute.VirtualMachineScaleSetsClientListAllResponse{VirtualMachineScaleSetListWithLinkResult: compute.VirtualMachineScaleSetListWithLinkResult{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages: pages,
+		groups: &pager[compute.VirtualMachineScaleSetsClientListOptions, compute.VirtualMachineScaleSetsClientListResponse, compute.VirtualMachineScaleSet]{
+			wrap: func(items []*compute.VirtualMachineScaleSet, nextLink *string) compute.VirtualMachineScaleSetsClientListResponse {
+				return compute.VirtualMachineScaleSetsClientListResponse{VirtualMachineScaleSetListResult: compute.VirtualMachineScaleSetListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+	}
+}
+
+// ResourceGroup sets the pages served when listing a single resource group.
+func (p *ScaleSetPager) ResourceGroup(name string, pages ...Page[compute.VirtualMachineScaleSet]) *ScaleSetPager {
+	p.set(name, pages)
+	return p
+}
+
+// GroupCalls returns the resource group listings received so far, in order.
+func (p *ScaleSetPager) GroupCalls() []Call[compute.VirtualMachineScaleSetsClientListOptions] {
+	return p.groups.Calls()
+}
+
+func (p *ScaleSetPager) NewListAllPager(options *compute.VirtualMachineScaleSetsClientListAllOptions) *rt.Pager[compute.VirtualMachineScaleSetsClientListAllResponse] {
+	return p.list(Call[compute.VirtualMachineScaleSetsClientListAllOptions]{Options: options}, p.pages)
+}
+
+func (p *ScaleSetPager) NewListPager(resourceGroupName string, options *compute.VirtualMachineScaleSetsClientListOptions) *rt.Pager[compute.VirtualMachineScaleSetsClientListResponse] {
+	return p.groups.list(Call[compute.VirtualMachineScaleSetsClientListOptions]{ResourceGroup: resourceGroupName, Options: options}, p.get(resourceGroupName))
+}
+
+// ScaleSetInstancePager is a fake scrapper.ScaleSetInstancePager, it serves its default pages for every scale set
+// unless pages were set for the scale set with ScaleSet.
+type ScaleSetInstancePager struct {
+	*pager[compute.VirtualMachineScaleSetVMsClientListOptions, compute.VirtualMachineScaleSetVMsClientListResponse, compute.VirtualMachineScaleSetVM]
+	pages []Page[compute.VirtualMachineScaleSetVM]
+
+	mu        sync.Mutex
+	scaleSets map[[2]string][]Page[compute.VirtualMachineScaleSetVM]
+}
+
+// NewScaleSetInstancePager serves the pages when listing the instances of any scale set.
+func NewScaleSetInstancePager(pages ...Page[compute.VirtualMachineScaleSetVM]) *ScaleSetInstancePager {
+	return &ScaleSetInstancePager{
+		pager: &pager[compute.VirtualMachineScaleSetVMsClientListOptions, compute.VirtualMachineScaleSetVMsClientListResponse, compute.VirtualMachineScaleSetVM]{
+			wrap: func(items []*compute.VirtualMachineScaleSetVM, nextLink *string) compute.VirtualMachineScaleSetVMsClientListResponse {
+				return compute.VirtualMachineScaleSetVMsClientListResponse{VirtualMachineScaleSetVMListResult: compute.VirtualMachineScaleSetVMListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages:     pages,
+		scaleSets: map[[2]string][]Page[compute.VirtualMachineScaleSetVM]{},
+	}
+}
+
+// ScaleSet sets the pages served when listing the instances of a single scale set.
+func (p *ScaleSetInstancePager) ScaleSet(resourceGroup string, name string, pages ...Page[compute.VirtualMachineScaleSetVM]) *ScaleSetInstancePager {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.scaleSets[[2]string{resourceGroup, name}] = pages
+	return p
+}
+
+func (p *ScaleSetInstancePager) NewListPager(resourceGroupName string, virtualMachineScaleSetName string, options *compute.VirtualMachineScaleSetVMsClientListOptions) *rt.Pager[compute.VirtualMachineScaleSetVMsClientListResponse] {
+	p.mu.Lock()
+	pages, ok := p.scaleSets[[2]string{resourceGroupName, virtualMachineScaleSetName}]
+	p.mu.Unlock()
+	if !ok {
+		pages = p.pages
+	}
+	return p.list(Call[compute.VirtualMachineScaleSetVMsClientListOptions]{ResourceGroup: resourceGroupName, ResourceName: virtualMachineScaleSetName, Options: options}, pages)
+}
+
+// NetworkSecurityGroupPager is a fake scrapper.NetworkSecurityGroupPager.
+type NetworkSecurityGroupPager struct {
+	*pager[network.SecurityGroupsClientListAllOptions, network.SecurityGroupsClientListAllResponse, network.SecurityGroup]
+	pages  []Page[network.SecurityGroup]
+	groups *pager[network.SecurityGroupsClientListOptions, network.SecurityGroupsClientListResponse, network.SecurityGroup]
+	groupPages[network.SecurityGroup]
+}
+
+// NewNetworkSecurityGroupPager serves the pages on every subscription wide listing.
+func NewNetworkSecurityGroupPager(pages ...Page[network.SecurityGroup]) *NetworkSecurityGroupPager {
+	return &NetworkSecurityGroupPager{
+		pager: &pager[network.SecurityGroupsClientListAllOptions, network.SecurityGroupsClientListAllResponse, network.SecurityGroup]{
+			wrap: func(items []*network.SecurityGroup, nextLink *string) network.SecurityGroupsClientListAllResponse {
+				return network.SecurityGroupsClientListAllResponse{SecurityGroupListResult: network.SecurityGroupListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages: pages,
+		groups: &pager[network.SecurityGroupsClientListOptions, network.SecurityGroupsClientListResponse, network.SecurityGroup]{
+			wrap: func(items []*network.SecurityGroup, nextLink *string) network.SecurityGroupsClientListResponse {
+				return network.SecurityGroupsClientListResponse{SecurityGroupListResult: network.SecurityGroupListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+	}
+}
+
+// ResourceGroup sets the pages served when listing a single resource group.
+func (p *NetworkSecurityGroupPager) ResourceGroup(name string, pages ...Page[network.SecurityGroup]) *NetworkSecurityGroupPager {
+	p.set(name, pages)
+	return p
+}
+
+// GroupCalls returns the resource group listings received so far, in order.
+func (p *NetworkSecurityGroupPager) GroupCalls() []Call[network.SecurityGroupsClientListOptions] {
+	return p.groups.Calls()
+}
+
+func (p *NetworkSecurityGroupPager) NewListAllPager(options *network.SecurityGroupsClientListAllOptions) *rt.Pager[network.SecurityGroupsClientListAllResponse] {
+	return p.list(Call[network.SecurityGroupsClientListAllOptions]{Options: options}, p.pages)
+}
+
+func (p *NetworkSecurityGroupPager) NewListPager(resourceGroupName string, options *network.SecurityGroupsClientListOptions) *rt.Pager[network.SecurityGroupsClientListResponse] {
+	return p.groups.list(Call[network.SecurityGroupsClientListOptions]{ResourceGroup: resourceGroupName, Options: options}, p.get(resourceGroupName))
+}
+
+// RouteTablePager is a fake scrapper.RouteTablePager.
+type RouteTablePager struct {
+	*pager[network.RouteTablesClientListAllOptions, network.RouteTablesClientListAllResponse, network.RouteTable]
+	pages  []Page[network.RouteTable]
+	groups *pager[network.RouteTablesClientListOptions, network.RouteTablesClientListResponse, network.RouteTable]
+	groupPages[network.RouteTable]
+}
+
+// NewRouteTablePager serves the pages on every subscription wide listing.
+func NewRouteTablePager(pages ...Page[network.RouteTable]) *RouteTablePager {
+	return &RouteTablePager{
+		pager: &pager[network.RouteTablesClientListAllOptions, network.RouteTablesClientListAllResponse, network.RouteTable]{
+			wrap: func(items []*network.RouteTable, nextLink *string) network.RouteTablesClientListAllResponse {
+				return network.RouteTablesClientListAllResponse{RouteTableListResult: network.RouteTableListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages: pages,
+		groups: &pager[network.RouteTablesClientListOptions, network.RouteTablesClientListResponse, network.RouteTable]{
+			wrap: func(items []*network.RouteTable, nextLink *string) network.RouteTablesClientListResponse {
+				return network.RouteTablesClientListResponse{RouteTableListResult: network.RouteTableListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+	}
+}
+
+// ResourceGroup sets the pages served when listing a single resource group.
+func (p *RouteTablePager) ResourceGroup(name string, pages ...Page[network.RouteTable]) *RouteTablePager {
+	p.set(name, pages)
+	return p
+}
+
+// GroupCalls returns the resource group listings received so far, in order.
+func (p *RouteTablePager) GroupCalls() []Call[network.RouteTablesClientListOptions] {
+	return p.groups.Calls()
+}
+
+func (p *RouteTablePager) NewListAllPager(options *network.RouteTablesClientListAllOptions) *rt.Pager[network.RouteTablesClientListAllResponse] {
+	return p.list(Call[network.RouteTablesClientListAllOptions]{Options: options}, p.pages)
+}
+
+func (p *RouteTablePager) NewListPager(resourceGroupName string, options *network.RouteTablesClientListOptions) *rt.Pager[network.RouteTablesClientListResponse] {
+	return p.groups.list(Call[network.RouteTablesClientListOptions]{ResourceGroup: resourceGroupName, Options: options}, p.get(resourceGroupName))
+}
+
+// DiskPager is a fake scrapper.DiskPager.
+type DiskPager struct {
+	*pager[compute.DisksClientListOptions, compute.DisksClientListResponse, compute.Disk]
+	pages  []Page[compute.Disk]
+	groups *pager[compute.DisksClientListByResourceGroupOptions, compute.DisksClientListByResourceGroupResponse, compute.Disk]
+	groupPages[compute.Disk]
+}
+
+// NewDiskPager serves the pages on every listing.
+func NewDiskPager(pages ...Page[compute.Disk]) *DiskPager {
+	return &DiskPager{
+		pager: &pager[compute.DisksClientListOptions, compute.DisksClientListResponse, compute.Disk]{
+			wrap: func(items []*compute.Disk, nextLink *string) compute.DisksClientListResponse {
+				return compute.DisksClientListResponse{DiskList: compute.DiskList{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages: pages,
+		groups: &pager[compute.DisksClientListByResourceGroupOptions, compute.DisksClientListByResourceGroupResponse, compute.Disk]{
+			wrap: func(items []*compute.Disk, nextLink *string) compute.DisksClientListByResourceGroupResponse {
+				return compute.DisksClientListByResourceGroupResponse{DiskList: compute.DiskList{Value: items, NextLink: nextLink}}
+			},
+		},
+	}
+}
+
+// ResourceGroup sets the pages served when listing a single resource group.
+func (p *DiskPager) ResourceGroup(name string, pages ...Page[compute.Disk]) *DiskPager {
+	p.set(name, pages)
+	return p
+}
+
+// GroupCalls returns the resource group listings received so far, in order.
+func (p *DiskPager) GroupCalls() []Call[compute.DisksClientListByResourceGroupOptions] {
+	return p.groups.Calls()
+}
+
+func (p *DiskPager) NewListPager(options *compute.DisksClientListOptions) *rt.Pager[compute.DisksClientListResponse] {
+	return p.list(Call[compute.DisksClientListOptions]{Options: options}, p.pages)
+}
+
+func (p *DiskPager) NewListByResourceGroupPager(resourceGroupName string, options *compute.DisksClientListByResourceGroupOptions) *rt.Pager[compute.DisksClientListByResourceGroupResponse] {
+	return p.groups.list(Call[compute.DisksClientListByResourceGroupOptions]{ResourceGroup: resourceGroupName, Options: options}, p.get(resourceGroupName))
+}
+
+// SnapshotPager is a fake scrapper.SnapshotPager.
+type SnapshotPager struct {
+	*pager[compute.SnapshotsClientListOptions, compute.SnapshotsClientListResponse, compute.Snapshot]
+	pages  []Page[compute.Snapshot]
+	groups *pager[compute.SnapshotsClientListByResourceGroupOptions, compute.SnapshotsClientListByResourceGroupResponse, compute.Snapshot]
+	groupPages[compute.Snapshot]
+}
+
+// NewSnapshotPager serves the pages on every listing.
+func NewSnapshotPager(pages ...Page[compute.Snapshot]) *SnapshotPager {
+	return &SnapshotPager{
+		pager: &pager[compute.SnapshotsClientListOptions, compute.SnapshotsClientListResponse, compute.Snapshot]{
+			wrap: func(items []*compute.Snapshot, nextLink *string) compute.SnapshotsClientListResponse {
+				return compute.SnapshotsClientListResponse{SnapshotList: compute.SnapshotList{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages: pages,
+		groups: &pager[compute.SnapshotsClientListByResourceGroupOptions, compute.SnapshotsClientListByResourceGroupResponse, compute.Snapshot]{
+			wrap: func(items []*compute.Snapshot, nextLink *string) compute.SnapshotsClientListByResourceGroupResponse {
+				return compute.SnapshotsClientListByResourceGroupResponse{SnapshotList: compute.SnapshotList{Value: items, NextLink: nextLink}}
+			},
+		},
+	}
+}
+
+// ResourceGroup sets the pages served when listing a single resource group.
+func (p *SnapshotPager) ResourceGroup(name string, pages ...Page[compute.Snapshot]) *SnapshotPager {
+	p.set(name, pages)
+	return p
+}
+
+// GroupCalls returns the resource group listings received so far, in order.
+func (p *SnapshotPager) GroupCalls() []Call[compute.SnapshotsClientListByResourceGroupOptions] {
+	return p.groups.Calls()
+}
+
+func (p *SnapshotPager) NewListPager(options *compute.SnapshotsClientListOptions) *rt.Pager[compute.SnapshotsClientListResponse] {
+	return p.list(Call[compute.SnapshotsClientListOptions]{Options: options}, p.pages)
+}
+
+func (p *SnapshotPager) NewListByResourceGroupPager(resourceGroupName string, options *compute.SnapshotsClientListByResourceGroupOptions) *rt.Pager[compute.SnapshotsClientListByResourceGroupResponse] {
+	return p.groups.list(Call[compute.SnapshotsClientListByResourceGroupOptions]{ResourceGroup: resourceGroupName, Options: options}, p.get(resourceGroupName))
+}
+
+// GenericResourcePager is a fake scrapper.GenericResourcePager.
+type GenericResourcePager struct {
+	*pager[resource.ClientListOptions, resource.ClientListResponse, resource.GenericResourceExpanded]
+	pages  []Page[resource.GenericResourceExpanded]
+	groups *pager[resource.ClientListByResourceGroupOptions, resource.ClientListByResourceGroupResponse, resource.GenericResourceExpanded]
+	groupPages[resource.GenericResourceExpanded]
+}
+
+// NewGenericResourcePager serves the pages on every listing.
+func NewGenericResourcePager(pages ...Page[resource.GenericResourceExpanded]) *GenericResourcePager {
+	return &GenericResourcePager{
+		pager: &pager[resource.ClientListOptions, resource.ClientListResponse, resource.GenericResourceExpanded]{
+			wrap: func(items []*resource.GenericResourceExpanded, nextLink *string) resource.ClientListResponse {
+				return resource.ClientListResponse{ResourceListResult: resource.ResourceListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+		pages: pages,
+		groups: &pager[resource.ClientListByResourceGroupOptions, resource.ClientListByResourceGroupResponse, resource.GenericResourceExpanded]{
+			wrap: func(items []*resource.GenericResourceExpanded, nextLink *string) resource.ClientListByResourceGroupResponse {
+				return resource.ClientListByResourceGroupResponse{ResourceListResult: resource.ResourceListResult{Value: items, NextLink: nextLink}}
+			},
+		},
+	}
+}
+
+// ResourceGroup sets the pages served when listing a single resource group.
+func (p *GenericResourcePager) ResourceGroup(name string, pages ...Page[resource.GenericResourceExpanded]) *GenericResourcePager {
+	p.set(name, pages)
+	return p
+}
+
+// GroupCalls returns the resource group listings received so far, in order.
+func (p *GenericResourcePager) GroupCalls() []Call[resource.ClientListByResourceGroupOptions] {
+	return p.groups.Calls()
+}
+
+func (p *GenericResourcePager) NewListPager(options *resource.ClientListOptions) *rt.Pager[resource.ClientListResponse] {
+	return p.list(Call[resource.ClientListOptions]{Options: options}, p.pages)
+}
+
+func (p *GenericResourcePager) NewListByResourceGroupPager(resourceGroupName string, options *resource.ClientListByResourceGroupOptions) *rt.Pager[resource.ClientListByResourceGroupResponse] {
+	return p.groups.list(Call[resource.ClientListByResourceGroupOptions]{ResourceGroup: resourceGroupName, Options: options}, p.get(resourceGroupName))
+}
@@ -0,0 +1,141 @@
+package scrapper
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseKinds converts resource kind names to kinds, failing on names no collector is registered for.
+func ParseKinds(names []string) ([]Kind, error) {
+	kinds := toKinds(names)
+	if err := validateKinds(kinds...); err != nil {
+		return nil, err
+	}
+	return kinds, nil
+}
+
+func toKinds(names []string) []Kind {
+	kinds := make([]Kind, 0, len(names))
+	for _, name := range names {
+		kinds = append(kinds, Kind(strings.TrimSpace(name)))
+	}
+	return kinds
+}
+
+func validateKinds(kinds ...Kind) error {
+	for _, kind := range kinds {
+		if _, ok := registered(kind); !ok {
+			return unknownKindError(kind)
+		}
+	}
+	return nil
+}
+
+func unknownKindError(kind Kind) error {
+	kinds := Kinds()
+	known := make([]string, 0, len(kinds))
+	for _, k := range kinds {
+		known = append(known, string(k))
+	}
+	return fmt.Errorf("unknown resource kind %q, expected one of %s", kind, strings.Join(known, ", "))
+}
+
+// selectKinds resolves which kinds are emitted, every registered kind that is not optional when include is empty,
+// minus the excluded ones.
+// The collected kinds are the emitted and required ones along with every kind they depend on, ordered by name.
+func selectKinds(include []Kind, exclude []Kind, required ...Kind) (emitted map[Kind]bool, collected []Kind, err error) {
+	if len(include) == 0 {
+		include = defaultKinds()
+	}
+	if err = validateKinds(append(append([]Kind{}, include...), exclude...)...); err != nil {
+		return nil, nil, err
+	}
+
+	emitted = map[Kind]bool{}
+	for _, kind := range include {
+		emitted[kind] = true
+	}
+	for _, kind := range exclude {
+		delete(emitted, kind)
+	}
+
+	collect := map[Kind]bool{}
+	var visit func(kind Kind) error
+	visit = func(kind Kind) error {
+		if collect[kind] {
+			return nil
+		}
+		c, ok := registered(kind)
+		if !ok {
+			return unknownKindError(kind)
+		}
+		collect[kind] = true
+		for _, dep := range c.dependsOn() {
+			if _, ok := registered(dep); !ok {
+				return fmt.Errorf("collector %s depends on unregistered kind %s", kind, dep)
+			}
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for kind := range emitted {
+		if err = visit(kind); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, kind := range required {
+		if err = visit(kind); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	for _, kind := range Kinds() {
+		if collect[kind] {
+			collected = append(collected, kind)
+		}
+	}
+	return emitted, collected, nil
+}
+
+// selectKinds resolves the kinds selected by the options, a resource group scope is validated first, adds the resource
+// groups to the collected kinds and leaves the subscription level kinds out.
+func (o *Options) selectKinds() (emitted map[Kind]bool, collected []Kind, err error) {
+	if o.scope == nil {
+		return selectKinds(o.include, o.exclude)
+	}
+	if err = o.scope.validate(); err != nil {
+		return nil, nil, err
+	}
+	exclude, err := scopedExclusions(o.include, o.exclude)
+	if err != nil {
+		return nil, nil, err
+	}
+	return selectKinds(o.include, exclude, KindResourceGroup)
+}
+
+// defaultKinds returns the registered kinds scraped when no kind is selected, ordered by name.
+func defaultKinds() []Kind {
+	var kinds []Kind
+	for _, kind := range Kinds() {
+		if c, ok := registered(kind); ok && !c.optional() {
+			kinds = append(kinds, kind)
+		}
+	}
+	return kinds
+}
+
+// WithKinds restricts the scrape to the given kinds, the kinds they depend on are collected without being emitted.
+func WithKinds(kinds ...Kind) OptionsFunc {
+	return func(opt *Options) {
+		opt.include = kinds
+	}
+}
+
+// WithoutKinds leaves the given kinds out of the scrape, they are still collected when an emitted kind depends on them.
+func WithoutKinds(kinds ...Kind) OptionsFunc {
+	return func(opt *Options) {
+		opt.exclude = kinds
+	}
+}
@@ -0,0 +1,117 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
+	"context"
+	"testing"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	container "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/containerservice/armcontainerservice/v2"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapper_SelectKinds(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []OptionsFunc
+		expect  func(t *testing.T, report *RunReport, records []Record, created []Kind)
+		wantErr string
+	}{
+		{
+			name:    "node pools imply listing clusters without emitting them",
+			options: []OptionsFunc{WithKinds(KindNodePool)},
+			expect: func(t *testing.T, report *RunReport, records []Record, created []Kind) {
+				assert.ElementsMatch(t, []Kind{KindCluster, KindNodePool}, created)
+				assert.Equal(t, map[Kind]int{KindNodePool: 2}, countKinds(records))
+				assert.Equal(t, 0, report.Kinds[KindCluster].Records)
+				assert.Equal(t, 1, report.Kinds[KindCluster].Pages)
+				assert.NotContains(t, report.Kinds, KindProvider)
+			},
+		},
+		{
+			name:    "excluded dependency is still collected",
+			options: []OptionsFunc{WithoutKinds(KindCluster, KindProvider)},
+			expect: func(t *testing.T, report *RunReport, records []Record, created []Kind) {
+				assert.NotContains(t, created, KindProvider)
+				assert.Equal(t, map[Kind]int{KindNodePool: 2, KindResourceGroup: 1}, countKinds(records))
+			},
+		},
+		{
+			name:    "every kind but the optional ones by default",
+			options: nil,
+			expect: func(t *testing.T, report *RunReport, records []Record, created []Kind) {
+				assert.Len(t, report.Kinds, len(Kinds())-2)
+				for _, optional := range []Kind{KindScaleSetInstance, KindGenericResource} {
+					assert.NotContains(t, report.Kinds, optional)
+					assert.NotContains(t, created, optional)
+				}
+				assert.Equal(t, 2, countKinds(records)[KindCluster])
+			},
+		},
+		{
+			name:    "optional kind once selected",
+			options: []OptionsFunc{WithKinds(KindScaleSetInstance)},
+			expect: func(t *testing.T, report *RunReport, records []Record, created []Kind) {
+				assert.ElementsMatch(t, []Kind{KindCluster, KindScaleSet, KindScaleSetInstance}, created)
+				assert.Contains(t, report.Kinds, KindScaleSetInstance)
+			},
+		},
+		{
+			name:    "unknown kind",
+			options: []OptionsFunc{WithKinds("cluster")},
+			wantErr: `unknown resource kind "cluster", expected one of`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var created []Kind
+			record := func(kind Kind) { created = append(created, kind) }
+
+			clusters := scrappertest.NewClusterPager(scrappertest.Items(
+				&container.ManagedCluster{ID: to(armClusterID("aks-1"))},
+				&container.ManagedCluster{ID: to(armClusterID("aks-2"))},
+			))
+			options := append(scrappertest.Recording(record),
+				WithClusterFactory(recordingFactory[ClusterPager](KindCluster, record, clusters)),
+				WithNodePoolFactory(recordingFactory[NodePoolPager](KindNodePool, record,
+					scrappertest.NewNodePoolPager(scrappertest.Items(&container.AgentPool{Name: to("system")})))),
+				WithResourceGroupsFactory(recordingFactory[ResourceGroupsPager](KindResourceGroup, record,
+					scrappertest.NewResourceGroupsPager(scrappertest.Items(&resource.ResourceGroup{Name: to("rg")})))),
+			)
+			sink := NewMemorySink()
+			s, err := NewScrapper(testCred(), "sub", append(append(options, WithSink(sink)), tt.options...)...)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			report, err := s.Run(context.Background())
+			require.NoError(t, err)
+			tt.expect(t, report, sink.Records(), created)
+		})
+	}
+}
+
+func recordingFactory[C any](kind Kind, record func(Kind), client C) ClientFactory[C] {
+	return func(_ string, _ az.TokenCredential, _ *arm.ClientOptions) (C, error) {
+		record(kind)
+		return client, nil
+	}
+}
+
+func countKinds(records []Record) map[Kind]int {
+	counts := map[Kind]int{}
+	for _, r := range records {
+		counts[r.Kind]++
+	}
+	return counts
+}
+
+func armClusterID(name string) string {
+	return "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.ContainerService/managedClusters/" + name
+}
@@ -0,0 +1,179 @@
+package scrapper
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+)
+
+// Sink receives every record emitted by the scrapper.
+// Open is called once before the first write and Close once after the last; Write may be called concurrently.
+type Sink interface {
+	Open(ctx context.Context) error
+	Write(record Record) error
+	Flush() error
+	Close() error
+}
+
+// jsonSink writes records as newline delimited json to an underlying writer.
+type jsonSink struct {
+	mu     sync.Mutex
+	open   func() (io.WriteCloser, error)
+	out    io.WriteCloser
+	buffer *bufio.Writer
+}
+
+// NewStdoutSink creates a sink that writes newline delimited json to os.Stdout.
+func NewStdoutSink() Sink {
+	return NewWriterSink(os.Stdout)
+}
+
+// NewWriterSink creates a sink that writes newline delimited json to w, closing the sink does not close w.
+func NewWriterSink(w io.Writer) Sink {
+	return &jsonSink{open: func() (io.WriteCloser, error) {
+		return nopCloser{w}, nil
+	}}
+}
+
+// NewFileSink creates a sink that writes newline delimited json to a local file, truncating it when opened.
+func NewFileSink(path string) Sink {
+	return &jsonSink{open: func() (io.WriteCloser, error) {
+		return os.Create(path)
+	}}
+}
+
+func (j *jsonSink) Open(_ context.Context) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	out, err := j.open()
+	if err != nil {
+		return err
+	}
+	j.out = out
+	j.buffer = bufio.NewWriter(out)
+	return nil
+}
+
+func (j *jsonSink) Write(record Record) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.buffer == nil {
+		return errors.New("sink is not open")
+	}
+	return json.NewEncoder(j.buffer).Encode(record)
+}
+
+func (j *jsonSink) Flush() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.buffer == nil {
+		return nil
+	}
+	return j.buffer.Flush()
+}
+
+func (j *jsonSink) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if j.out == nil {
+		return nil
+	}
+	err := errors.Join(j.buffer.Flush(), j.out.Close())
+	j.out, j.buffer = nil, nil
+	return err
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// MemorySink collects records in memory, mostly useful for tests and for returning records to a caller.
+type MemorySink struct {
+	mu      sync.Mutex
+	records []Record
+}
+
+// NewMemorySink creates an empty in-memory sink.
+func NewMemorySink() *MemorySink {
+	return &MemorySink{}
+}
+
+func (m *MemorySink) Open(_ context.Context) error {
+	return nil
+}
+
+func (m *MemorySink) Write(record Record) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.records = append(m.records, record)
+	return nil
+}
+
+func (m *MemorySink) Flush() error {
+	return nil
+}
+
+func (m *MemorySink) Close() error {
+	return nil
+}
+
+// Records returns a copy of every record written so far.
+func (m *MemorySink) Records() []Record {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Record(nil), m.records...)
+}
+
+// multiSink fans every record out to several sinks.
+type multiSink []Sink
+
+// NewMultiSink creates a sink that writes every record to each of the given sinks.
+func NewMultiSink(sinks ...Sink) Sink {
+	return multiSink(sinks)
+}
+
+func (m multiSink) Open(ctx context.Context) error {
+	for _, sink := range m {
+		if err := sink.Open(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiSink) Write(record Record) error {
+	for _, sink := range m {
+		if err := sink.Write(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m multiSink) Flush() error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, sink.Flush())
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiSink) Close() error {
+	var errs []error
+	for _, sink := range m {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,64 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.json")
+	sink := NewFileSink(path)
+
+	require.NoError(t, sink.Open(context.Background()))
+	require.NoError(t, sink.Write(Record{Kind: KindProvider, SubscriptionID: "sub", RunID: "run", Payload: "first"}))
+	require.NoError(t, sink.Write(Record{Kind: KindCluster, SubscriptionID: "sub", RunID: "run", Payload: "second"}))
+	require.NoError(t, sink.Flush())
+	require.NoError(t, sink.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, `{"kind":"provider","subscriptionId":"sub","runId":"run","scrapedAt":"0001-01-01T00:00:00Z","payload":"first"}
+{"kind":"managedCluster","subscriptionId":"sub","runId":"run","scrapedAt":"0001-01-01T00:00:00Z","payload":"second"}
+`, string(content))
+}
+
+func TestFileSink_WriteBeforeOpen(t *testing.T) {
+	sink := NewFileSink(filepath.Join(t.TempDir(), "inventory.json"))
+	assert.Error(t, sink.Write(Record{}))
+}
+
+func TestFileSink_OpenFails(t *testing.T) {
+	sink := NewFileSink(filepath.Join(t.TempDir(), "missing", "inventory.json"))
+	assert.Error(t, sink.Open(context.Background()))
+}
+
+func TestMemorySink(t *testing.T) {
+	sink := NewMemorySink()
+
+	require.NoError(t, sink.Open(context.Background()))
+	require.NoError(t, sink.Write(Record{Payload: "first"}))
+	require.NoError(t, sink.Write(Record{Payload: "second"}))
+	require.NoError(t, sink.Flush())
+	require.NoError(t, sink.Close())
+
+	assert.Equal(t, []Record{{Payload: "first"}, {Payload: "second"}}, sink.Records())
+}
+
+func TestMultiSink(t *testing.T) {
+	first, second := NewMemorySink(), NewMemorySink()
+	sink := NewMultiSink(first, second)
+
+	require.NoError(t, sink.Open(context.Background()))
+	require.NoError(t, sink.Write(Record{Payload: "first"}))
+	require.NoError(t, sink.Flush())
+	require.NoError(t, sink.Close())
+
+	assert.Equal(t, []Record{{Payload: "first"}}, first.Records())
+	assert.Equal(t, []Record{{Payload: "first"}}, second.Records())
+}
@@ -0,0 +1,41 @@
+package scrapper
+
+import (
+	"context"
+	network "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v2"
+)
+
+const KindSubnet Kind = "subnet"
+
+func init() {
+	Register(Collector[any]{
+		Kind:      KindSubnet,
+		DependsOn: []Kind{KindVirtualNetwork},
+		Collect: func(ctx context.Context, c *Collection, _ any) error {
+			vnets := Collected[network.VirtualNetwork](c, KindVirtualNetwork)
+			return ListSubnets(ctx, vnets, emitHandler[Subnet](c, KindSubnet))
+		},
+	})
+}
+
+// Subnet is a subnet together with the id of the virtual network it belongs to.
+type Subnet struct {
+	VirtualNetworkID string          `json:"virtualNetworkId"`
+	Subnet           *network.Subnet `json:"subnet"`
+}
+
+// ListSubnets hands the subnets nested in the virtual networks to the page handler.
+func ListSubnets(ctx context.Context, vnets []*network.VirtualNetwork, pageHandler pageHandler[Subnet]) error {
+	var subnets []*Subnet
+	for _, vnet := range vnets {
+		if vnet.ID == nil || vnet.Properties == nil {
+			continue
+		}
+		for _, subnet := range vnet.Properties.Subnets {
+			if subnet != nil {
+				subnets = append(subnets, &Subnet{VirtualNetworkID: *vnet.ID, Subnet: subnet})
+			}
+		}
+	}
+	return processPage(ctx, subnets, pageHandler)
+}
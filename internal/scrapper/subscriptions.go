@@ -0,0 +1,25 @@
+package scrapper
+
+import (
+	"context"
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	subscription "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armsubscriptions"
+)
+
+// SubscriptionPager used to discover the subscriptions visible to a credential
+type SubscriptionPager interface {
+	NewListPager(options *subscription.ClientListOptions) *rt.Pager[subscription.ClientListResponse]
+}
+
+type SubscriptionClientFactory func(credential az.TokenCredential, options *arm.ClientOptions) (SubscriptionPager, error)
+
+func defaultSubscriptionClientFactory(credential az.TokenCredential, options *arm.ClientOptions) (SubscriptionPager, error) {
+	return subscription.NewClient(credential, options)
+}
+
+func (m *MultiScrapper) ListSubscriptions(ctx context.Context, pageHandler pageHandler[subscription.Subscription]) error {
+	pager := m.subscriptionClient.NewListPager(nil)
+	return listPages(ctx, pager, func(p subscription.ClientListResponse) []*subscription.Subscription { return p.Value }, pageHandler)
+}
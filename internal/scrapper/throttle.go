@@ -0,0 +1,229 @@
+package scrapper
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+)
+
+const (
+	headerRemainingReads = "x-ms-ratelimit-remaining-subscription-reads"
+	headerRetryAfter     = "Retry-After"
+)
+
+// ThrottleOptions configures how a Throttle paces requests.
+type ThrottleOptions struct {
+	// MaxConcurrency is how many requests can be in flight while the read budget is healthy.
+	MaxConcurrency int
+	// MinInterval is the minimum delay between two requests while the read budget is healthy.
+	MinInterval time.Duration
+	// MaxInterval is the delay between two requests once the read budget is exhausted.
+	MaxInterval time.Duration
+	// LowRemaining is the remaining read budget under which requests are slowed down and sent one at a time.
+	LowRemaining int
+	// DefaultPause is how long requests are paused after a 429 without a Retry-After header.
+	DefaultPause time.Duration
+}
+
+// DefaultThrottleOptions returns pacing suited to the ARM subscription read limits.
+func DefaultThrottleOptions() ThrottleOptions {
+	return ThrottleOptions{
+		MaxConcurrency: 8,
+		MaxInterval:    5 * time.Second,
+		LowRemaining:   200,
+		DefaultPause:   10 * time.Second,
+	}
+}
+
+// Throttle paces the requests of every client sharing it according to the ARM throttling headers.
+// Requests slow down as the remaining read budget drops and a 429 pauses every request until its Retry-After elapsed,
+// throttled requests are retried by the retry policy of the clients.
+type Throttle struct {
+	opts ThrottleOptions
+
+	mu          sync.Mutex
+	inFlight    int
+	remaining   int
+	next        time.Time
+	pausedUntil time.Time
+	changed     chan struct{}
+}
+
+// NewThrottle creates a throttle, zero options fields other than MinInterval take their default value.
+func NewThrottle(opts ThrottleOptions) *Throttle {
+	def := DefaultThrottleOptions()
+	if opts.MaxConcurrency <= 0 {
+		opts.MaxConcurrency = def.MaxConcurrency
+	}
+	if opts.MaxInterval <= 0 {
+		opts.MaxInterval = def.MaxInterval
+	}
+	if opts.LowRemaining <= 0 {
+		opts.LowRemaining = def.LowRemaining
+	}
+	if opts.DefaultPause <= 0 {
+		opts.DefaultPause = def.DefaultPause
+	}
+	return &Throttle{
+		opts:      opts,
+		remaining: -1,
+		changed:   make(chan struct{}),
+	}
+}
+
+// Policy returns the pipeline policy pacing requests through the throttle, it should be added as a per retry policy.
+func (t *Throttle) Policy() policy.Policy {
+	return throttlePolicy{t}
+}
+
+// Remaining returns the last remaining read budget reported by ARM, or -1 when none was reported yet.
+func (t *Throttle) Remaining() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.remaining
+}
+
+// Interval returns the current delay between two requests.
+func (t *Throttle) Interval() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.interval()
+}
+
+// PausedUntil returns when requests resume after a 429, it is in the past when requests are not paused.
+func (t *Throttle) PausedUntil() time.Time {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pausedUntil
+}
+
+func (t *Throttle) low() bool {
+	return t.remaining >= 0 && t.remaining < t.opts.LowRemaining
+}
+
+func (t *Throttle) interval() time.Duration {
+	if !t.low() {
+		return t.opts.MinInterval
+	}
+	spread := t.opts.MaxInterval - t.opts.MinInterval
+	return t.opts.MinInterval + spread*time.Duration(t.opts.LowRemaining-t.remaining)/time.Duration(t.opts.LowRemaining)
+}
+
+func (t *Throttle) concurrency() int {
+	if t.low() {
+		return 1
+	}
+	return t.opts.MaxConcurrency
+}
+
+// acquire blocks until a request can be sent, the caller must release it once the response is received.
+func (t *Throttle) acquire(ctx context.Context) error {
+	for {
+		t.mu.Lock()
+		now := time.Now()
+		ready := t.next
+		if t.pausedUntil.After(ready) {
+			ready = t.pausedUntil
+		}
+		if t.inFlight < t.concurrency() && !ready.After(now) {
+			t.inFlight++
+			t.next = now.Add(t.interval())
+			t.mu.Unlock()
+			return nil
+		}
+		changed := t.changed
+		t.mu.Unlock()
+
+		var wait <-chan time.Time
+		var timer *time.Timer
+		if d := ready.Sub(now); d > 0 {
+			timer = time.NewTimer(d)
+			wait = timer.C
+		}
+		select {
+		case <-ctx.Done():
+			err := ctx.Err()
+			stopTimer(timer)
+			return err
+		case <-changed:
+		case <-wait:
+		}
+		stopTimer(timer)
+	}
+}
+
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// release frees the slot of a request and updates the budget from its response.
+func (t *Throttle) release(resp *http.Response) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.inFlight--
+	if resp != nil {
+		if v, err := strconv.Atoi(resp.Header.Get(headerRemainingReads)); err == nil {
+			t.remaining = v
+		}
+		if resp.StatusCode == http.StatusTooManyRequests {
+			until := time.Now().Add(retryAfter(resp, t.opts.DefaultPause))
+			if until.After(t.pausedUntil) {
+				t.pausedUntil = until
+			}
+		}
+	}
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// retryAfter reads the Retry-After header, expressed either in seconds or as an http date.
+func retryAfter(resp *http.Response, fallback time.Duration) time.Duration {
+	v := resp.Header.Get(headerRetryAfter)
+	if v == "" {
+		return fallback
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		return time.Until(at)
+	}
+	return fallback
+}
+
+type throttlePolicy struct {
+	throttle *Throttle
+}
+
+// Do sends the request once the throttle allows it, a 429 is returned to the retry policy
+// whose next attempt waits here for the pause to elapse.
+func (p throttlePolicy) Do(req *policy.Request) (*http.Response, error) {
+	if err := p.throttle.acquire(req.Raw().Context()); err != nil {
+		return nil, err
+	}
+	resp, err := req.Next()
+	p.throttle.release(resp)
+	return resp, err
+}
+
+// WithThrottling paces the requests of each scrapper with its own Throttle,
+// subscription read limits are per subscription so scrappers of different subscriptions do not slow each other down.
+func WithThrottling(opts ThrottleOptions) OptionsFunc {
+	return func(opt *Options) {
+		opt.throttling = &opts
+	}
+}
+
+// WithThrottle paces the requests of every client with the given throttle, sharing it between scrappers.
+func WithThrottle(t *Throttle) OptionsFunc {
+	return func(opt *Options) {
+		opt.throttle = t
+	}
+}
@@ -0,0 +1,114 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/cloud"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/policy"
+	resource "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/resources/armresources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottle(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     ThrottleOptions
+		retry    policy.RetryOptions
+		respond  func(w http.ResponseWriter, call int32)
+		wantErr  string
+		expected func(t *testing.T, throttle *Throttle, calls int32, elapsed time.Duration)
+	}{
+		{
+			name: "healthy budget keeps the base pace",
+			opts: ThrottleOptions{LowRemaining: 100, MaxInterval: time.Second},
+			respond: func(w http.ResponseWriter, _ int32) {
+				w.Header().Set("x-ms-ratelimit-remaining-subscription-reads", "11999")
+			},
+			expected: func(t *testing.T, throttle *Throttle, calls int32, _ time.Duration) {
+				assert.Equal(t, int32(1), calls)
+				assert.Equal(t, 11999, throttle.Remaining())
+				assert.Zero(t, throttle.Interval())
+			},
+		},
+		{
+			name: "low budget slows requests down",
+			opts: ThrottleOptions{LowRemaining: 100, MaxInterval: time.Second},
+			respond: func(w http.ResponseWriter, _ int32) {
+				w.Header().Set("x-ms-ratelimit-remaining-subscription-reads", "25")
+			},
+			expected: func(t *testing.T, throttle *Throttle, _ int32, _ time.Duration) {
+				assert.Equal(t, 25, throttle.Remaining())
+				assert.Equal(t, 750*time.Millisecond, throttle.Interval())
+			},
+		},
+		{
+			name:  "429 pauses until the retry policy sends the request again",
+			opts:  ThrottleOptions{},
+			retry: policy.RetryOptions{MaxRetries: 3, RetryDelay: time.Millisecond},
+			respond: func(w http.ResponseWriter, call int32) {
+				if call == 1 {
+					w.Header().Set("Retry-After", "1")
+					w.WriteHeader(http.StatusTooManyRequests)
+				}
+			},
+			expected: func(t *testing.T, throttle *Throttle, calls int32, elapsed time.Duration) {
+				assert.Equal(t, int32(2), calls)
+				assert.GreaterOrEqual(t, elapsed, 900*time.Millisecond)
+				assert.False(t, throttle.PausedUntil().IsZero())
+			},
+		},
+		{
+			name:  "throttled requests are only retried by the retry policy",
+			opts:  ThrottleOptions{},
+			retry: policy.RetryOptions{MaxRetries: 2, RetryDelay: time.Millisecond},
+			respond: func(w http.ResponseWriter, _ int32) {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			wantErr: "429 Too Many Requests",
+			expected: func(t *testing.T, _ *Throttle, calls int32, _ time.Duration) {
+				assert.Equal(t, int32(3), calls)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				tt.respond(w, atomic.AddInt32(&calls, 1))
+				_, _ = w.Write([]byte(`{"value":[{"namespace":"Microsoft.Compute"}]}`))
+			}))
+			defer srv.Close()
+
+			throttle := NewThrottle(tt.opts)
+			s, err := NewScrapper(&scopeRecordingCred{}, "sub", WithThrottle(throttle), WithSink(NewMemorySink()),
+				WithCloud(cloud.Configuration{Services: map[cloud.ServiceName]cloud.ServiceConfiguration{
+					cloud.ResourceManager: {Endpoint: srv.URL, Audience: "https://management.example"},
+				}}),
+				WithClientOptions(&arm.ClientOptions{ClientOptions: policy.ClientOptions{
+					Transport: srv.Client(),
+					Retry:     tt.retry,
+				}}),
+			)
+			require.NoError(t, err)
+
+			start := time.Now()
+			err = s.ListProviders(context.Background(), func(p *resource.Provider) error { return nil })
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			tt.expected(t, throttle, atomic.LoadInt32(&calls), time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package scrapper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
+	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+	"golang.org/x/sync/errgroup"
+)
+
+const KindVirtualMachine Kind = "virtualMachine"
+
+// VirtualMachinePager used to scrape virtual machine information
+type VirtualMachinePager interface {
+	NewListAllPager(options *compute.VirtualMachinesClientListAllOptions) *rt.Pager[compute.VirtualMachinesClientListAllResponse]
+	NewListPager(resourceGroupName string, options *compute.VirtualMachinesClientListOptions) *rt.Pager[compute.VirtualMachinesClientListResponse]
+	InstanceView(ctx context.Context, resourceGroupName string, vmName string, options *compute.VirtualMachinesClientInstanceViewOptions) (compute.VirtualMachinesClientInstanceViewResponse, error)
+}
+
+type VirtualMachineClientFactory = ClientFactory[VirtualMachinePager]
+
+func init() {
+	Register(Collector[VirtualMachinePager]{
+		Kind: KindVirtualMachine,
+		Factory: func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (VirtualMachinePager, error) {
+			return compute.NewVirtualMachinesClient(subscriptionID, credential, options)
+		},
+		Collect: func(ctx context.Context, c *Collection, _ VirtualMachinePager) error {
+			return c.Scrapper().ListVirtualMachines(ctx, emitHandler[VirtualMachine](c, KindVirtualMachine))
+		},
+		ListByResourceGroup: func(ctx context.Context, c *Collection, _ VirtualMachinePager, resourceGroup string) error {
+			return c.Scrapper().ListVirtualMachinesByResourceGroup(ctx, resourceGroup, emitHandler[VirtualMachine](c, KindVirtualMachine))
+		},
+		Graph: &GraphQuery{
+			Query: graphResources("microsoft.compute/virtualmachines"),
+			Collect: func(ctx context.Context, c *Collection, rows []json.RawMessage) error {
+				return virtualMachineRows(ctx, rows, c.Scrapper().kindConfig(KindVirtualMachine).expand, emitHandler[VirtualMachine](c, KindVirtualMachine))
+			},
+		},
+	})
+}
+
+func WithVirtualMachineFactory(f VirtualMachineClientFactory) OptionsFunc {
+	return WithFactory(KindVirtualMachine, f)
+}
+
+// VirtualMachine is a virtual machine together with its power state, which is only read when WithInstanceView is set.
+type VirtualMachine struct {
+	PowerState     string                  `json:"powerState,omitempty"`
+	VirtualMachine *compute.VirtualMachine `json:"virtualMachine"`
+}
+
+func (s *Scrapper) ListVirtualMachines(ctx context.Context, handler pageHandler[VirtualMachine]) error {
+	pager := clientFor[VirtualMachinePager](s, KindVirtualMachine).NewListAllPager(nil)
+	return s.listVirtualMachines(ctx, func(ctx context.Context, vmHandler pageHandler[compute.VirtualMachine]) error {
+		return listPages(ctx, pager, func(p compute.VirtualMachinesClientListAllResponse) []*compute.VirtualMachine { return p.Value }, vmHandler)
+	}, handler)
+}
+
+func (s *Scrapper) ListVirtualMachinesByResourceGroup(ctx context.Context, resourceGroup string, handler pageHandler[VirtualMachine]) error {
+	pager := clientFor[VirtualMachinePager](s, KindVirtualMachine).NewListPager(resourceGroup, nil)
+	return s.listVirtualMachines(ctx, func(ctx context.Context, vmHandler pageHandler[compute.VirtualMachine]) error {
+		return listPages(ctx, pager, func(p compute.VirtualMachinesClientListResponse) []*compute.VirtualMachine { return p.Value }, vmHandler)
+	}, handler)
+}
+
+// listVirtualMachines hands the listed virtual machines to pageHandler, reading the instance view of each
+// with at most the configured concurrency of workers when WithInstanceView is set. Virtual machines deleted
+// before their instance view is read are handed without a power state.
+func (s *Scrapper) listVirtualMachines(ctx context.Context, list func(context.Context, pageHandler[compute.VirtualMachine]) error, pageHandler pageHandler[VirtualMachine]) error {
+	config := s.kindConfig(KindVirtualMachine)
+	if !config.expand {
+		return list(ctx, func(vm *compute.VirtualMachine) error {
+			return pageHandler(&VirtualMachine{VirtualMachine: vm})
+		})
+	}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(config.concurrency)
+	err := list(gctx, func(vm *compute.VirtualMachine) error {
+		if vm.ID == nil {
+			return pageHandler(&VirtualMachine{VirtualMachine: vm})
+		}
+		id, err := arm.ParseResourceID(*vm.ID)
+		if err != nil {
+			return fmt.Errorf("failed to parse virtual machine id: %w", err)
+		}
+		g.Go(func() error {
+			view, err := clientFor[VirtualMachinePager](s, KindVirtualMachine).InstanceView(gctx, id.ResourceGroupName, id.Name, nil)
+			if notFound(err) {
+				return pageHandler(&VirtualMachine{VirtualMachine: vm})
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read instance view of %s: %w", id.Name, err)
+			}
+			return pageHandler(&VirtualMachine{PowerState: PowerState(view.Statuses), VirtualMachine: vm})
+		})
+		return nil
+	})
+	// a failed instance view cancels the listing, its error is the cause
+	if waitErr := g.Wait(); waitErr != nil {
+		return waitErr
+	}
+	return err
+}
+
+// virtualMachineRows emits the virtual machines of a page of resource graph rows. When instanceView is set their power
+// state is read from the extended properties resource graph reports, which saves a request per virtual machine.
+func virtualMachineRows(ctx context.Context, rows []json.RawMessage, instanceView bool, pageHandler pageHandler[VirtualMachine]) error {
+	page := make([]*VirtualMachine, 0, len(rows))
+	for _, row := range rows {
+		vm := &VirtualMachine{VirtualMachine: &compute.VirtualMachine{}}
+		if err := json.Unmarshal(row, vm.VirtualMachine); err != nil {
+			return fmt.Errorf("failed to decode resource graph row: %w", err)
+		}
+		if !instanceView {
+			page = append(page, vm)
+			continue
+		}
+		var extended struct {
+			Properties struct {
+				Extended struct {
+					InstanceView struct {
+						PowerState compute.InstanceViewStatus `json:"powerState"`
+					} `json:"instanceView"`
+				} `json:"extended"`
+			} `json:"properties"`
+		}
+		if err := json.Unmarshal(row, &extended); err != nil {
+			return fmt.Errorf("failed to decode resource graph row: %w", err)
+		}
+		vm.PowerState = PowerState([]*compute.InstanceViewStatus{&extended.Properties.Extended.InstanceView.PowerState})
+		page = append(page, vm)
+	}
+	return processPage(ctx, page, pageHandler)
+}
+
+// PowerState returns the power state of an instance view, such as running or deallocated, or an empty string if it has none.
+func PowerState(statuses []*compute.InstanceViewStatus) string {
+	for _, status := range statuses {
+		if status == nil || status.Code == nil {
+			continue
+		}
+		if state, ok := strings.CutPrefix(*status.Code, "PowerState/"); ok {
+			return state
+		}
+	}
+	return ""
+}
+
+// notFound reports whether err is an azure response for a resource that no longer exists.
+func notFound(err error) bool {
+	var respErr *az.ResponseError
+	return errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound
+}
+
+// WithInstanceView reads the power state of virtual machines and scale set instances from their instance view,
+// it costs a request per virtual machine.
+func WithInstanceView() OptionsFunc {
+	return func(opt *Options) {
+		opt.configureKind(func(c *kindConfig) { c.expand = true }, KindVirtualMachine, KindScaleSetInstance)
+	}
+}
+
+// WithComputeConcurrency limits how many virtual machines have their instance view read, and how many scale sets
+// have their instances listed, at the same time. Values below 1 keep the default of 5.
+func WithComputeConcurrency(n int) OptionsFunc {
+	return func(opt *Options) {
+		opt.configureKind(func(c *kindConfig) { c.concurrency = n }, KindVirtualMachine, KindScaleSetInstance)
+	}
+}
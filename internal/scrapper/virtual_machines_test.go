@@ -0,0 +1,150 @@
+package scrapper_test
+
+import (
+	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	compute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrapper_ListVirtualMachines(t *testing.T) {
+	vmID := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Compute/virtualMachines/"
+	running := &compute.VirtualMachineInstanceView{Statuses: []*compute.InstanceViewStatus{
+		{Code: to("ProvisioningState/succeeded")},
+		{Code: to("PowerState/running")},
+	}}
+	tests := []struct {
+		name         string
+		pager        *scrappertest.VirtualMachinePager
+		options      []OptionsFunc
+		handlerError error
+		expect       func(t *testing.T, vms []*VirtualMachine, err error)
+	}{
+		{
+			name:  "power state is not read by default",
+			pager: scrappertest.NewVirtualMachinePager(scrappertest.Items(&compute.VirtualMachine{ID: to(vmID + "vm-1")})).SetInstanceView("rg", "vm-1", running),
+			expect: func(t *testing.T, vms []*VirtualMachine, err error) {
+				require.NoError(t, err)
+				require.Len(t, vms, 1)
+				assert.Empty(t, vms[0].PowerState)
+				assert.Equal(t, vmID+"vm-1", *vms[0].VirtualMachine.ID)
+			},
+		},
+		{
+			name: "power state from the instance view",
+			pager: scrappertest.NewVirtualMachinePager(
+				scrappertest.Items(&compute.VirtualMachine{ID: to(vmID + "vm-1")}),
+				scrappertest.Items(&compute.VirtualMachine{ID: to(vmID + "vm-2")}),
+			).SetInstanceView("rg", "vm-1", running),
+			options: []OptionsFunc{WithInstanceView()},
+			expect: func(t *testing.T, vms []*VirtualMachine, err error) {
+				require.NoError(t, err)
+				states := map[string]string{}
+				for _, vm := range vms {
+					states[*vm.VirtualMachine.ID] = vm.PowerState
+				}
+				assert.Equal(t, map[string]string{vmID + "vm-1": "running", vmID + "vm-2": ""}, states)
+			},
+		},
+		{
+			name: "virtual machines deleted before their instance view is read have no power state",
+			pager: scrappertest.NewVirtualMachinePager(scrappertest.Items(
+				&compute.VirtualMachine{ID: to(vmID + "vm-1")},
+				&compute.VirtualMachine{ID: to(vmID + "vm-2")},
+			)).SetInstanceView("rg", "vm-1", running).
+				FailInstanceView("rg", "vm-2", &az.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: "NotFound"}),
+			options: []OptionsFunc{WithInstanceView()},
+			expect: func(t *testing.T, vms []*VirtualMachine, err error) {
+				require.NoError(t, err)
+				states := map[string]string{}
+				for _, vm := range vms {
+					states[*vm.VirtualMachine.ID] = vm.PowerState
+				}
+				assert.Equal(t, map[string]string{vmID + "vm-1": "running", vmID + "vm-2": ""}, states)
+			},
+		},
+		{
+			name: "instance view fails",
+			pager: scrappertest.NewVirtualMachinePager(scrappertest.Items(&compute.VirtualMachine{ID: to(vmID + "vm-1")})).
+				FailInstanceView("rg", "vm-1", &az.ResponseError{StatusCode: http.StatusForbidden, ErrorCode: "AuthorizationFailed"}),
+			options: []OptionsFunc{WithInstanceView()},
+			expect: func(t *testing.T, vms []*VirtualMachine, err error) {
+				assert.ErrorContains(t, err, "failed to read instance view of vm-1")
+				assert.Empty(t, vms)
+			},
+		},
+		{
+			name: "a failed instance view stops the listing",
+			pager: scrappertest.NewVirtualMachinePager(
+				scrappertest.Items(&compute.VirtualMachine{ID: to(vmID + "vm-1")}),
+				scrappertest.Block[compute.VirtualMachine](),
+			).FailInstanceView("rg", "vm-1", errors.New("boom")),
+			options: []OptionsFunc{WithInstanceView()},
+			expect: func(t *testing.T, vms []*VirtualMachine, err error) {
+				assert.ErrorContains(t, err, "boom")
+			},
+		},
+		{
+			name:         "handler fails",
+			pager:        scrappertest.NewVirtualMachinePager(scrappertest.Items(&compute.VirtualMachine{ID: to(vmID + "vm-1")})),
+			options:      []OptionsFunc{WithInstanceView()},
+			handlerError: errors.New("failed to Handle virtual machine"),
+			expect: func(t *testing.T, vms []*VirtualMachine, err error) {
+				assert.ErrorContains(t, err, "failed to Handle virtual machine")
+			},
+		},
+		{
+			name:  "iteration fails",
+			pager: scrappertest.NewVirtualMachinePager(scrappertest.Fail[compute.VirtualMachine](errors.New("boom"))),
+			expect: func(t *testing.T, vms []*VirtualMachine, err error) {
+				assert.ErrorContains(t, err, "boom")
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]OptionsFunc{WithVirtualMachineFactory(scrappertest.Factory[VirtualMachinePager](tt.pager))}, tt.options...)
+			scraper, err := NewScrapper(testCred(), "not-important", options...)
+			require.NoError(t, err)
+
+			var mu sync.Mutex
+			var vms []*VirtualMachine
+			err = scraper.ListVirtualMachines(context.Background(), func(vm *VirtualMachine) error {
+				mu.Lock()
+				defer mu.Unlock()
+				vms = append(vms, vm)
+				return tt.handlerError
+			})
+			tt.expect(t, vms, err)
+		})
+	}
+}
+
+func TestPowerState(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []*compute.InstanceViewStatus
+		want     string
+	}{
+		{name: "no status", want: ""},
+		{name: "provisioning only", statuses: []*compute.InstanceViewStatus{{Code: to("ProvisioningState/succeeded")}}, want: ""},
+		{
+			name:     "deallocated",
+			statuses: []*compute.InstanceViewStatus{{}, {Code: to("ProvisioningState/succeeded")}, {Code: to("PowerState/deallocated")}},
+			want:     "deallocated",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, PowerState(tt.statuses))
+		})
+	}
+}
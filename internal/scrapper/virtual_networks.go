@@ -2,34 +2,54 @@ package scrapper
 
 import (
 	"context"
-	"fmt"
+	"encoding/json"
 	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	rt "github.com/Azure/azure-sdk-for-go/sdk/azcore/runtime"
 	network "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v2"
 )
 
+const KindVirtualNetwork Kind = "virtualNetwork"
+
 // VirtualNetworkPager used to scrape virtual network information
 type VirtualNetworkPager interface {
 	NewListAllPager(options *network.VirtualNetworksClientListAllOptions) *rt.Pager[network.VirtualNetworksClientListAllResponse]
+	NewListPager(resourceGroupName string, options *network.VirtualNetworksClientListOptions) *rt.Pager[network.VirtualNetworksClientListResponse]
 }
 
-type VirtualNetworkClientFactory func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (VirtualNetworkPager, error)
+type VirtualNetworkClientFactory = ClientFactory[VirtualNetworkPager]
+
+func init() {
+	Register(Collector[VirtualNetworkPager]{
+		Kind: KindVirtualNetwork,
+		Factory: func(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (VirtualNetworkPager, error) {
+			return network.NewVirtualNetworksClient(subscriptionID, credential, options)
+		},
+		Collect: func(ctx context.Context, c *Collection, _ VirtualNetworkPager) error {
+			return c.Scrapper().ListVirtualNetworks(ctx, emitHandler[network.VirtualNetwork](c, KindVirtualNetwork))
+		},
+		ListByResourceGroup: func(ctx context.Context, c *Collection, _ VirtualNetworkPager, resourceGroup string) error {
+			return c.Scrapper().ListVirtualNetworksByResourceGroup(ctx, resourceGroup, emitHandler[network.VirtualNetwork](c, KindVirtualNetwork))
+		},
+		Graph: &GraphQuery{
+			Query: graphResources("microsoft.network/virtualnetworks"),
+			Collect: func(ctx context.Context, c *Collection, rows []json.RawMessage) error {
+				return graphRows(ctx, rows, emitHandler[network.VirtualNetwork](c, KindVirtualNetwork))
+			},
+		},
+	})
+}
 
-func defaultNetworkClientFactory(subscriptionID string, credential az.TokenCredential, options *arm.ClientOptions) (VirtualNetworkPager, error) {
-	return network.NewVirtualNetworksClient(subscriptionID, credential, options)
+func WithVirtualNetworksFactory(f VirtualNetworkClientFactory) OptionsFunc {
+	return WithFactory(KindVirtualNetwork, f)
 }
 
 func (s *Scrapper) ListVirtualNetworks(ctx context.Context, pageHandler pageHandler[network.VirtualNetwork]) error {
-	pager := s.networksClient.NewListAllPager(nil)
-	for pager.More() {
-		page, err := pager.NextPage(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to advance page: %w", err)
-		}
-		if err = processPage(page.Value, err, pageHandler); err != nil {
-			return err
-		}
-	}
-	return nil
+	pager := clientFor[VirtualNetworkPager](s, KindVirtualNetwork).NewListAllPager(nil)
+	return listPages(ctx, pager, func(p network.VirtualNetworksClientListAllResponse) []*network.VirtualNetwork { return p.Value }, pageHandler)
+}
+
+func (s *Scrapper) ListVirtualNetworksByResourceGroup(ctx context.Context, resourceGroup string, pageHandler pageHandler[network.VirtualNetwork]) error {
+	pager := clientFor[VirtualNetworkPager](s, KindVirtualNetwork).NewListPager(resourceGroup, nil)
+	return listPages(ctx, pager, func(p network.VirtualNetworksClientListResponse) []*network.VirtualNetwork { return p.Value }, pageHandler)
 }
@@ -2,11 +2,10 @@ package scrapper_test
 
 import (
 	. "azure-scrapper/internal/scrapper"
+	"azure-scrapper/internal/scrapper/scrappertest"
 	"context"
 	"errors"
 
-	az "github.com/Azure/azure-sdk-for-go/sdk/azcore"
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/arm"
 	network "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/network/armnetwork/v2"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -21,37 +20,23 @@ func TestScrapper_ListVirtualNetworks(t *testing.T) {
 		want                   func(t *testing.T, err error)
 	}{
 		{
-			name: "virtual network iteration succeeds",
-			virtualNetworksFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (VirtualNetworkPager, error) {
-				return NewPager[network.VirtualNetworksClientListAllOptions, network.VirtualNetworksClientListAllResponse]{item: &network.VirtualNetworksClientListAllResponse{
-					VirtualNetworkListResult: network.VirtualNetworkListResult{
-						Value: []*network.VirtualNetwork{{}},
-					},
-				}}, nil
-			},
+			name:                   "virtual network iteration succeeds",
+			virtualNetworksFactory: scrappertest.Factory[VirtualNetworkPager](scrappertest.NewVirtualNetworkPager(scrappertest.Items(&network.VirtualNetwork{}))),
 			want: func(t *testing.T, err error) {
 				assert.NoError(t, err)
 			},
 		},
 		{
-			name: "virtual network handler fails",
-			virtualNetworksFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (VirtualNetworkPager, error) {
-				return NewPager[network.VirtualNetworksClientListAllOptions, network.VirtualNetworksClientListAllResponse]{item: &network.VirtualNetworksClientListAllResponse{
-					VirtualNetworkListResult: network.VirtualNetworkListResult{
-						Value: []*network.VirtualNetwork{{}},
-					},
-				}}, nil
-			},
-			handlerError: errors.New("failed to Handle virtual network"),
+			name:                   "virtual network handler fails",
+			virtualNetworksFactory: scrappertest.Factory[VirtualNetworkPager](scrappertest.NewVirtualNetworkPager(scrappertest.Items(&network.VirtualNetwork{}))),
+			handlerError:           errors.New("failed to Handle virtual network"),
 			want: func(t *testing.T, err error) {
 				assert.Error(t, err)
 			},
 		},
 		{
-			name: "virtual network iteration fails",
-			virtualNetworksFactory: func(sub string, cred az.TokenCredential, opts *arm.ClientOptions) (VirtualNetworkPager, error) {
-				return FailPager[network.VirtualNetworksClientListAllOptions, network.VirtualNetworksClientListAllResponse]{}, nil
-			},
+			name:                   "virtual network iteration fails",
+			virtualNetworksFactory: scrappertest.Factory[VirtualNetworkPager](scrappertest.NewVirtualNetworkPager(scrappertest.Fail[network.VirtualNetwork](errors.New("failed to iterate")))),
 			want: func(t *testing.T, err error) {
 				assert.Error(t, err)
 			},